@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 
 	"github.com/komari-monitor/komari-agent/cmd/flags"
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
 )
 
 // AutoDiscoveryConfig 自动发现配置结构体
@@ -97,7 +98,7 @@ func registerWithAutoDiscovery() error {
 		Key: flags.AutoDiscoveryKey,
 	}
 
-	hostname, _ := os.Hostname()
+	hostname := monitoring.Hostname()
 
 	jsonData, err := json.Marshal(requestData)
 	if err != nil {
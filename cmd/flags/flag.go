@@ -20,6 +20,34 @@ var (
 	CFAccessClientSecret string
 	MemoryIncludeCache   bool
 	CustomDNS            string
-	EnableGPU            bool // 启用详细GPU监控
-	ShowWarning          bool // Windows 上显示安全警告，作为子进程运行一次
+	EnableGPU            bool   // 启用详细GPU监控
+	ShowWarning          bool   // Windows 上显示安全警告，作为子进程运行一次
+	PingSource           string // 探测默认源网卡或源 IP
+	PingFamily           string // 探测默认地址族：ipv4 / ipv6
+	SpeedtestDownloadURL string // 测速下载地址，以 / 开头表示 Komari 服务端路径
+	SpeedtestUploadURL   string // 测速上传地址，以 / 开头表示 Komari 服务端路径
+	SpeedtestMaxBytes    int64  // 单次测速上下行合计的最大传输字节数
+	ProcessTopN          int    // 上报 CPU / 内存占用最高的进程数量，0 为关闭
+	WatchProcesses       string // 需要保持运行的进程（名称/命令行正则），分号分隔
+	WatchUnits           string // 需要保持运行的 systemd 单元，逗号分隔
+	LogFormat            string // 日志格式：text / journald
+	EnableContainers     bool   // 上报 Docker/Podman 容器列表与资源占用
+	ContainerSocket      string // Docker/Podman Engine API 套接字路径，为空则自动探测
+	CgroupAware          bool   // 按 cgroup 限制计算内存和 CPU 使用率，并上报 cgroup 统计
+	CgroupPath           string // 指定 cgroup 路径（相对于 cgroup 挂载点），为空则使用 agent 自身所在 cgroup
+	Kubernetes           bool   // 以 DaemonSet 方式运行，使用节点名作为身份
+	KubernetesHostMount  string // 宿主机 /proc、/sys、/etc 在 Pod 内的挂载目录
+	HostRoot             string // 宿主机根目录的挂载路径，容器内监控宿主机时使用
+	EnableDiskHealth     bool   // 检查 md RAID 阵列与 SMART 磁盘健康状态
+	DryRun               bool   // 只在日志中输出将要上报的数据，不连接服务端
+	TrafficStateFile     string // 月流量统计状态文件路径，为空则保存在程序所在目录
+	TrafficQuota         string // 流量配额：[网卡|total:]in/out/sum/max:大小，分号分隔
+	TrafficQuotaWarn     string // 流量配额告警百分比，逗号分隔
+	TrafficQuotaAction   string // 流量配额用尽时执行的本地命令
+	IPDiscovery          string // 公网 IP 获取方式，逗号分隔按顺序尝试：web / local / stun，none 为关闭
+	IPEndpoints          string // 公网 IP 查询接口，逗号分隔，为空使用内置列表；以 / 开头表示 Komari 服务端路径
+	StunServer           string // STUN 方式使用的服务器 host:port
+	ConnectionPeers      int    // 上报 TCP 连接数最多的远端 IP 数量，0 为关闭
+	EnableListeners      bool   // 上报监听端口及所属进程，变化时推送事件
+	PerCoreCPU           bool   // 上报每个核心的使用率、频率与降频情况
 )
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	report "github.com/komari-monitor/komari-agent/monitoring"
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+	"github.com/spf13/cobra"
+)
+
+var inspectOutput string
+
+var InspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Show what each collector detects",
+	Long:  `Show what each collector detects and why items are included or excluded, using the same flags as the agent`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		setupLocalCollectors()
+	},
+}
+
+var inspectNicsCmd = &cobra.Command{
+	Use:   "nics",
+	Short: "List network interfaces and whether they are counted",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		nics, err := monitoring.InspectNics()
+		if err != nil {
+			return err
+		}
+		return printInspect(cmd.OutOrStdout(), nics, func(w *tabwriter.Writer) {
+			fmt.Fprintln(w, "Name\tSource\tIncluded\tSent\tReceived\tReason")
+			for _, n := range nics {
+				fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%d\t%s\n", n.Name, n.Source, n.Included, n.BytesSent, n.BytesRecv, n.Reason)
+			}
+		})
+	},
+}
+
+var inspectDisksCmd = &cobra.Command{
+	Use:   "disks",
+	Short: "List partitions and whether they are counted",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		disks, err := monitoring.InspectDisks()
+		if err != nil {
+			return err
+		}
+		info := monitoring.Disk()
+		v := map[string]interface{}{"partitions": disks, "total": info.Total, "used": info.Used, "pools": info.Pools}
+		return printInspect(cmd.OutOrStdout(), v, func(w *tabwriter.Writer) {
+			fmt.Fprintln(w, "Mountpoint\tDevice\tFstype\tIncluded\tReason")
+			for _, d := range disks {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", d.Mountpoint, d.Device, d.Fstype, d.Included, d.Reason)
+			}
+			fmt.Fprintf(w, "\nTotal: %d bytes, used: %d bytes\n", info.Total, info.Used)
+			for _, p := range info.Pools {
+				fmt.Fprintf(w, "Pool %s (%s): size %d, allocated %d, health %s\n", p.Name, p.Type, p.Size, p.Allocated, p.Health)
+			}
+		})
+	},
+}
+
+var inspectGpuCmd = &cobra.Command{
+	Use:   "gpu",
+	Short: "Show detected GPUs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		v := map[string]interface{}{"name": monitoring.GpuName()}
+		var detailed []monitoring.DetailedGPUInfo
+		var detailedErr error
+		if flags.EnableGPU {
+			detailed, detailedErr = monitoring.GetDetailedGPUInfo()
+			v["detailed"] = detailed
+			if detailedErr != nil {
+				v["error"] = detailedErr.Error()
+			}
+		}
+		return printInspect(cmd.OutOrStdout(), v, func(w *tabwriter.Writer) {
+			fmt.Fprintf(w, "Name:\t%s\n", v["name"])
+			if !flags.EnableGPU {
+				fmt.Fprintln(w, "Detailed:\tdisabled (use --gpu)")
+				return
+			}
+			if detailedErr != nil {
+				fmt.Fprintf(w, "Detailed:\t%v\n", detailedErr)
+				return
+			}
+			fmt.Fprintln(w, "\nName\tMemory Used\tMemory Total\tUtilization\tTemperature")
+			for _, g := range detailed {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%.1f%%\t%d\n", g.Name, g.MemoryUsed, g.MemoryTotal, g.Utilization, g.Temperature)
+			}
+		})
+	},
+}
+
+var inspectOsCmd = &cobra.Command{
+	Use:   "os",
+	Short: "Show detected operating system and CPU",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cpu := monitoring.Cpu()
+		v := map[string]interface{}{
+			"os":             monitoring.OSName(),
+			"kernel_version": monitoring.KernelVersion(),
+			"hostname":       monitoring.Hostname(),
+			"host_root":      monitoring.HostRoot(),
+			"cpu_name":       cpu.CPUName,
+			"cpu_cores":      cpu.CPUCores,
+			"arch":           cpu.CPUArchitecture,
+			"goos":           runtime.GOOS,
+		}
+		return printInspect(cmd.OutOrStdout(), v, printKeyValues(v))
+	},
+}
+
+var inspectVirtCmd = &cobra.Command{
+	Use:   "virt",
+	Short: "Show detected virtualization and how it was detected",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		virt, method := monitoring.DetectVirtualization()
+		v := map[string]interface{}{"virtualization": virt, "method": method}
+		return printInspect(cmd.OutOrStdout(), v, printKeyValues(v))
+	},
+}
+
+var inspectListenersCmd = &cobra.Command{
+	Use:   "listeners",
+	Short: "List listening TCP/UDP ports and their owning processes",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listeners, err := monitoring.Listeners()
+		if err != nil {
+			return err
+		}
+		return printInspect(cmd.OutOrStdout(), listeners, func(w *tabwriter.Writer) {
+			fmt.Fprintln(w, "Proto\tAddress\tPort\tPID\tProcess\tUser")
+			for _, l := range listeners {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\n", l.Proto, l.Address, l.Port, l.PID, l.Process, l.User)
+			}
+		})
+	},
+}
+
+var inspectIPCmd = &cobra.Command{
+	Use:   "ip",
+	Short: "Try every configured public IP discovery method and show the results",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		probes := monitoring.ProbeIPAddress()
+		return printInspect(cmd.OutOrStdout(), probes, func(w *tabwriter.Writer) {
+			fmt.Fprintln(w, "Family\tAPI\tIP\tError")
+			for _, p := range probes {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Family, p.API, p.IP, p.Error)
+			}
+		})
+	},
+}
+
+var inspectReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generate one report exactly as it would be sent",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var v map[string]interface{}
+		if err := json.Unmarshal(report.GenerateReport(), &v); err != nil {
+			return err
+		}
+		return printInspect(cmd.OutOrStdout(), v, func(w *tabwriter.Writer) {
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				data, _ := json.Marshal(v[k])
+				fmt.Fprintf(w, "%s\t%s\n", k, data)
+			}
+		})
+	},
+}
+
+// printInspect 按 --output 输出 JSON 或表格
+func printInspect(out io.Writer, v interface{}, table func(w *tabwriter.Writer)) error {
+	switch inspectOutput {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "table", "":
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, use table or json", inspectOutput)
+	}
+}
+
+func printKeyValues(v map[string]interface{}) func(w *tabwriter.Writer) {
+	return func(w *tabwriter.Writer) {
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s:\t%v\n", k, v[k])
+		}
+	}
+}
+
+func init() {
+	InspectCmd.PersistentFlags().StringVarP(&inspectOutput, "output", "o", "table", "Output format: table or json")
+	for _, c := range []*cobra.Command{inspectNicsCmd, inspectDisksCmd, inspectGpuCmd, inspectOsCmd, inspectVirtCmd, inspectListenersCmd, inspectIPCmd, inspectReportCmd} {
+		c.SilenceUsage = true // 采集失败时只输出错误，不打印用法
+		InspectCmd.AddCommand(c)
+	}
+	RootCmd.AddCommand(InspectCmd)
+}
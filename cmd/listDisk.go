@@ -33,6 +33,17 @@ var ListDiskCmd = &cobra.Command{
 			return
 		}
 		log.Println("Monitoring Mountpoints:", diskList)
+
+		w = tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Mountpoint\tFstype\tInodes Used\tInodes Total\tInodes Use%\tRead-only")
+		for _, m := range monitoring.Disk().Mounts {
+			percent := "-"
+			if m.InodesTotal > 0 {
+				percent = fmt.Sprintf("%.1f%%", float64(m.InodesUsed)/float64(m.InodesTotal)*100)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%t\n", m.Mountpoint, m.Fstype, m.InodesUsed, m.InodesTotal, percent, m.ReadOnly)
+		}
+		_ = w.Flush()
 	},
 }
 
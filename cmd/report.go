@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/komari-monitor/komari-agent/dnsresolver"
+	report "github.com/komari-monitor/komari-agent/monitoring"
+	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
+	"github.com/komari-monitor/komari-agent/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportOnce    bool
+	reportCompact bool
+)
+
+var ReportCmd = &cobra.Command{
+	Use:          "report",
+	Short:        "Print the payloads the agent would send",
+	Long:         `Collect basic info and monitoring reports locally and print them as JSON instead of sending them to the server`,
+	SilenceUsage: true,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		setupLocalCollectors()
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		monitoring.StartWatchdog()
+		monitoring.StartDiskHealth()
+		monitoring.StartNetInterfaceWatch()
+		monitoring.StartListenerWatch()
+
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		if !reportCompact {
+			enc.SetIndent("", "  ")
+		}
+		if reportOnce {
+			var data json.RawMessage = report.GenerateReport()
+			return enc.Encode(map[string]interface{}{
+				"basic_info": server.BasicInfo(),
+				"report":     data,
+			})
+		}
+
+		if err := enc.Encode(map[string]interface{}{"basic_info": server.BasicInfo()}); err != nil {
+			return err
+		}
+		ticker := time.NewTicker(server.ReportInterval())
+		defer ticker.Stop()
+		for ; ; <-ticker.C {
+			var data json.RawMessage = report.GenerateReport()
+			if err := enc.Encode(map[string]interface{}{"report": data}); err != nil {
+				return err
+			}
+		}
+	},
+}
+
+// setupLocalCollectors 本地诊断命令与主程序使用相同的 DNS、Kubernetes 与宿主机根目录设置，
+// 流量统计只读取状态文件，不影响正在运行的 agent
+func setupLocalCollectors() {
+	monitoring.SetTrafficReadOnly()
+	if flags.CustomDNS != "" {
+		dnsresolver.SetCustomDNSServer(flags.CustomDNS)
+	}
+	monitoring.SetupKubernetesMode()
+	monitoring.SetupHostRoot()
+}
+
+func init() {
+	ReportCmd.Flags().BoolVar(&reportOnce, "once", false, "Print a single report together with the basic info and exit")
+	ReportCmd.Flags().BoolVar(&reportCompact, "compact", false, "Print compact JSON (one object per line) instead of indented JSON")
+	RootCmd.AddCommand(ReportCmd)
+}
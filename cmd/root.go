@@ -8,6 +8,7 @@ import (
 
 	"github.com/komari-monitor/komari-agent/cmd/flags"
 	"github.com/komari-monitor/komari-agent/dnsresolver"
+	"github.com/komari-monitor/komari-agent/logger"
 	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
 	"github.com/komari-monitor/komari-agent/server"
 	"github.com/komari-monitor/komari-agent/update"
@@ -29,6 +30,10 @@ var RootCmd = &cobra.Command{
 			go WarnKomariRunning()
 		}
 
+		if err := logger.SetFormat(flags.LogFormat); err != nil {
+			log.Printf("Failed to set log format %q, using text: %v", flags.LogFormat, err)
+		}
+
 		log.Println("Komari Agent", update.CurrentVersion)
 		log.Println("Github Repo:", update.Repo)
 
@@ -41,8 +46,16 @@ var RootCmd = &cobra.Command{
 			log.Printf("Using system default DNS resolver")
 		}
 
+		monitoring.SetupKubernetesMode()
+		monitoring.SetupHostRoot()
+		if flags.DryRun {
+			monitoring.SetTrafficReadOnly()
+		}
+
 		// Auto discovery
-		if flags.AutoDiscoveryKey != "" {
+		if flags.AutoDiscoveryKey != "" && flags.DryRun {
+			log.Println("Dry run: skipping auto-discovery registration")
+		} else if flags.AutoDiscoveryKey != "" {
 			err := handleAutoDiscovery()
 			if err != nil {
 				log.Printf("Auto-discovery failed: %v", err)
@@ -59,6 +72,16 @@ var RootCmd = &cobra.Command{
 			log.Println("Failed to get interface list:", err)
 		}
 		log.Println("Monitoring Interfaces:", interfaceList)
+		monitoring.StartWatchdog()
+		monitoring.StartDiskHealth()
+		monitoring.StartTrafficQuota()
+		monitoring.StartNetInterfaceWatch()
+		monitoring.StartListenerWatch()
+
+		if flags.DryRun {
+			server.RunDryRun()
+			return
+		}
 
 		// 忽略不安全的证书
 		if flags.IgnoreUnsafeCert {
@@ -118,11 +141,39 @@ func init() {
 	RootCmd.PersistentFlags().StringVar(&flags.ExcludeNics, "exclude-nics", "", "Comma-separated list of network interfaces to exclude")
 	RootCmd.PersistentFlags().StringVar(&flags.IncludeMountpoints, "include-mountpoint", "", "Semicolon-separated list of mount points to include for disk statistics")
 	RootCmd.PersistentFlags().IntVar(&flags.MonthRotate, "month-rotate", 0, "Month reset for network statistics (0 to disable)")
+	RootCmd.PersistentFlags().StringVar(&flags.TrafficStateFile, "traffic-state-file", "", "File storing per-interface traffic counters for --month-rotate (defaults to komari-traffic.json next to the executable)")
+	RootCmd.PersistentFlags().StringVar(&flags.TrafficQuota, "traffic-quota", "", "Semicolon-separated traffic quotas per billing period as [interface|total:]in|out|sum|max:size, e.g. \"total:sum:1TB;eth0:out:500GiB\"")
+	RootCmd.PersistentFlags().StringVar(&flags.TrafficQuotaWarn, "traffic-quota-warn", "80,90,100", "Comma-separated usage percentages at which traffic quota warnings are emitted")
+	RootCmd.PersistentFlags().StringVar(&flags.TrafficQuotaAction, "traffic-quota-action", "", "Command to run once per billing period when a traffic quota is used up (KOMARI_QUOTA_* environment variables describe the quota)")
+	RootCmd.PersistentFlags().StringVar(&flags.IPDiscovery, "ip-discovery", "web", "Comma-separated public IP discovery methods tried in order: web, local (public interface addresses, or private ones when listed last), stun; none to disable")
+	RootCmd.PersistentFlags().StringVar(&flags.IPEndpoints, "ip-endpoints", "", "Comma-separated IP echo URLs for the web method, replacing the built-in list (paths starting with / are relative to the endpoint)")
+	RootCmd.PersistentFlags().StringVar(&flags.StunServer, "stun-server", "stun.cloudflare.com:3478", "STUN server (host:port) for the stun IP discovery method")
+	RootCmd.PersistentFlags().IntVar(&flags.ConnectionPeers, "connection-peers", 0, "Report the top N remote IPs by TCP connection count (0 to disable)")
 	RootCmd.PersistentFlags().StringVar(&flags.CFAccessClientID, "cf-access-client-id", "", "Cloudflare Access Client ID")
 	RootCmd.PersistentFlags().StringVar(&flags.CFAccessClientSecret, "cf-access-client-secret", "", "Cloudflare Access Client Secret")
 	RootCmd.PersistentFlags().BoolVar(&flags.MemoryIncludeCache, "memory-include-cache", false, "Include cache/buffer in memory usage")
 	RootCmd.PersistentFlags().StringVar(&flags.CustomDNS, "custom-dns", "", "Custom DNS server to use (e.g. 8.8.8.8, 114.114.114.114). By default, the program uses the system DNS resolver.")
 	RootCmd.PersistentFlags().BoolVar(&flags.EnableGPU, "gpu", false, "Enable detailed GPU monitoring (usage, memory, multi-GPU support)")
 	RootCmd.PersistentFlags().BoolVar(&flags.ShowWarning, "show-warning", false, "Show security warning on Windows, run once as a subprocess")
+	RootCmd.PersistentFlags().StringVar(&flags.PingSource, "ping-source", "", "Default source interface or IP address for ping tasks")
+	RootCmd.PersistentFlags().StringVar(&flags.PingFamily, "ping-family", "", "Default address family for ping tasks (ipv4 or ipv6, empty for any)")
+	RootCmd.PersistentFlags().StringVar(&flags.SpeedtestDownloadURL, "speedtest-download-url", "", "Default download URL for speedtest tasks (paths starting with / are relative to the endpoint)")
+	RootCmd.PersistentFlags().StringVar(&flags.SpeedtestUploadURL, "speedtest-upload-url", "", "Default upload URL for speedtest tasks (paths starting with / are relative to the endpoint)")
+	RootCmd.PersistentFlags().Int64Var(&flags.SpeedtestMaxBytes, "speedtest-max-bytes", 200*1024*1024, "Maximum total bytes transferred by a single speedtest task (0 for unlimited)")
+	RootCmd.PersistentFlags().IntVar(&flags.ProcessTopN, "process-top", 0, "Report the top N processes by CPU and memory usage (0 to disable)")
+	RootCmd.PersistentFlags().StringVar(&flags.WatchProcesses, "watch-process", "", "Semicolon-separated list of process name/cmdline regexes that must be running")
+	RootCmd.PersistentFlags().StringVar(&flags.WatchUnits, "watch-unit", "", "Comma-separated list of systemd units that must be running")
+	RootCmd.PersistentFlags().StringVar(&flags.LogFormat, "log-format", "text", "Log format: text or journald (structured logging with task_id/session_id fields, Linux only)")
+	RootCmd.PersistentFlags().BoolVar(&flags.EnableContainers, "containers", false, "Enable Docker/Podman container inventory and per-container metrics")
+	RootCmd.PersistentFlags().StringVar(&flags.ContainerSocket, "container-socket", "", "Docker/Podman Engine API socket path (auto-detected by default)")
+	RootCmd.PersistentFlags().BoolVar(&flags.CgroupAware, "cgroup-aware", false, "Report memory and CPU usage relative to cgroup limits and include cgroup statistics (Linux only)")
+	RootCmd.PersistentFlags().StringVar(&flags.CgroupPath, "cgroup", "", "Cgroup path to report, relative to the cgroup mount (defaults to the agent's own cgroup)")
+	RootCmd.PersistentFlags().BoolVar(&flags.Kubernetes, "kubernetes", false, "Run as a Kubernetes DaemonSet: report the node (NODE_NAME) instead of the pod")
+	RootCmd.PersistentFlags().StringVar(&flags.KubernetesHostMount, "kubernetes-host-mount", "/host", "Directory where the host's /proc, /sys and /etc are mounted in Kubernetes mode")
+	RootCmd.PersistentFlags().StringVar(&flags.HostRoot, "host-root", "", "Path where the host's root filesystem is mounted (e.g. /host), used when monitoring the host from a container")
+	RootCmd.PersistentFlags().BoolVar(&flags.EnableDiskHealth, "disk-health", false, "Report md RAID state from /proc/mdstat and SMART health via smartctl, alerting on changes")
+	RootCmd.PersistentFlags().BoolVar(&flags.EnableListeners, "listeners", false, "Report listening TCP/UDP ports and their owning processes, alerting when listeners appear or disappear")
+	RootCmd.PersistentFlags().BoolVar(&flags.PerCoreCPU, "per-core-cpu", false, "Report per-core CPU usage, frequency and thermal throttle counts")
+	RootCmd.Flags().BoolVar(&flags.DryRun, "dry-run", false, "Collect and log basic info and reports instead of sending them to the server")
 	RootCmd.PersistentFlags().ParseErrorsWhitelist.UnknownFlags = true
 }
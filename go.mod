@@ -13,6 +13,7 @@ require (
 	github.com/rhysd/go-github-selfupdate v1.2.3
 	github.com/shirou/gopsutil/v4 v4.25.6
 	github.com/spf13/cobra v1.9.1
+	golang.org/x/net v0.38.0
 	golang.org/x/sys v0.33.0
 	gopkg.in/toast.v1 v1.0.0-20180812000517-0a84660828b2
 )
@@ -34,7 +35,6 @@ require (
 	github.com/ulikunitz/xz v0.5.9 // indirect
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
 	golang.org/x/crypto v0.39.0 // indirect
-	golang.org/x/net v0.38.0 // indirect
 	golang.org/x/oauth2 v0.30.0 // indirect
 	golang.org/x/sync v0.13.0 // indirect
 )
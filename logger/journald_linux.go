@@ -0,0 +1,62 @@
+//go:build linux
+// +build linux
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"strings"
+)
+
+const journalSocket = "/run/systemd/journal/socket"
+
+// journalWriter 通过 journald 原生协议发送日志
+type journalWriter struct {
+	conn *net.UnixConn
+}
+
+func newJournalWriter() (*journalWriter, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journalWriter{conn: conn}, nil
+}
+
+func (w *journalWriter) send(priority int, fields Fields, message string) error {
+	_, err := w.conn.Write(encodeJournalEntry(journalFields(priority, fields, message)))
+	return err
+}
+
+// Write 实现 io.Writer，供标准 log 包使用；发送失败时直接写入标准错误输出，
+// 切换标准 log 输出需要在 log 包的锁之外进行，因此放到新的 goroutine 中
+func (w *journalWriter) Write(p []byte) (int, error) {
+	if err := w.send(priorityInfo, nil, strings.TrimRight(string(p), "\n")); err != nil {
+		go journalFailed(err)
+		return os.Stderr.Write(p)
+	}
+	return len(p), nil
+}
+
+// encodeJournalEntry 按 journald 原生协议编码，含换行的值使用二进制长度前缀格式
+func encodeJournalEntry(fields [][2]string) []byte {
+	var buf bytes.Buffer
+	for _, f := range fields {
+		if strings.ContainsRune(f[1], '\n') {
+			buf.WriteString(f[0])
+			buf.WriteByte('\n')
+			_ = binary.Write(&buf, binary.LittleEndian, uint64(len(f[1])))
+			buf.WriteString(f[1])
+			buf.WriteByte('\n')
+			continue
+		}
+		buf.WriteString(f[0])
+		buf.WriteByte('=')
+		buf.WriteString(f[1])
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
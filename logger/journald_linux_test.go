@@ -0,0 +1,61 @@
+//go:build linux
+// +build linux
+
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeJournalEntry(t *testing.T) {
+	fields := journalFields(priorityInfo, Fields{"task_id": "42", "session-id": "abc", "empty": ""}, "line1\nline2")
+	got := encodeJournalEntry(fields)
+
+	var want bytes.Buffer
+	want.WriteString("MESSAGE\n")
+	_ = binary.Write(&want, binary.LittleEndian, uint64(len("line1\nline2")))
+	want.WriteString("line1\nline2\n")
+	want.WriteString("PRIORITY=6\nSYSLOG_IDENTIFIER=komari-agent\nSESSION_ID=abc\nTASK_ID=42\n")
+
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("encodeJournalEntry mismatch:\n got %q\nwant %q", got, want.Bytes())
+	}
+}
+
+func TestJournalFailureFallsBackToStderr(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	l, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram unavailable: %v", err)
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 接收端关闭后发送失败，相当于 journald 失效
+	l.Close()
+	os.Remove(path)
+	defer conn.Close()
+
+	setJournal(&journalWriter{conn: conn})
+	log.SetOutput(&journalWriter{conn: conn})
+	defer func() {
+		setJournal(nil)
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	Printf(nil, "message after journald failure")
+	if currentJournal() != nil {
+		t.Error("journald should be disabled after a failed send")
+	}
+	if log.Writer() != os.Stderr {
+		t.Error("standard log should be switched back to stderr")
+	}
+}
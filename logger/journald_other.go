@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package logger
+
+import "errors"
+
+// journalWriter 仅在 Linux 上可用
+type journalWriter struct{}
+
+func newJournalWriter() (*journalWriter, error) {
+	return nil, errors.New("journald is only supported on Linux")
+}
+
+func (w *journalWriter) send(priority int, fields Fields, message string) error {
+	return errors.New("journald is only supported on Linux")
+}
+
+func (w *journalWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("journald is only supported on Linux")
+}
@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Fields 结构化日志字段，如 task_id、session_id
+type Fields map[string]string
+
+// 日志优先级，与 syslog 一致
+const (
+	priorityErr  = 3
+	priorityInfo = 6
+)
+
+var (
+	journalMu sync.Mutex
+	journald  *journalWriter
+)
+
+// SetFormat 设置日志格式："text"（默认，写入标准 log）或 "journald"
+func SetFormat(format string) error {
+	switch strings.ToLower(format) {
+	case "", "text":
+		setJournal(nil)
+		return nil
+	case "journald":
+		w, err := newJournalWriter()
+		if err != nil {
+			return err
+		}
+		setJournal(w)
+		// 其余未改造的 log.Println 也写入 journald，作为无字段的普通消息
+		log.SetFlags(0)
+		log.SetOutput(w)
+		return nil
+	default:
+		return fmt.Errorf("unsupported log format: %s", format)
+	}
+}
+
+// Printf 输出带字段的普通日志
+func Printf(fields Fields, format string, v ...interface{}) {
+	output(priorityInfo, fields, fmt.Sprintf(format, v...))
+}
+
+// Errorf 输出带字段的错误日志
+func Errorf(fields Fields, format string, v ...interface{}) {
+	output(priorityErr, fields, fmt.Sprintf(format, v...))
+}
+
+func output(priority int, fields Fields, message string) {
+	message = strings.TrimRight(message, "\n")
+	if w := currentJournal(); w != nil {
+		err := w.send(priority, fields, message)
+		if err == nil {
+			return
+		}
+		journalFailed(err)
+	}
+	log.Print(message)
+}
+
+func setJournal(w *journalWriter) {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	journald = w
+}
+
+func currentJournal() *journalWriter {
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	return journald
+}
+
+// journalFailed journald 发送失败（如 systemd-journald 重启、套接字失效）后切回标准错误输出，
+// 标准 log 不再写入失效的 journald 套接字，之后的日志不会丢失
+func journalFailed(err error) {
+	journalMu.Lock()
+	if journald == nil {
+		journalMu.Unlock()
+		return
+	}
+	journald = nil
+	journalMu.Unlock()
+	log.SetOutput(os.Stderr)
+	log.SetFlags(log.LstdFlags)
+	log.Printf("Failed to write to journald, logging to stderr: %v", err)
+}
+
+// journalFields 将字段名转换为 journald 要求的格式（大写字母、数字和下划线，且不以下划线开头）
+func journalFields(priority int, fields Fields, message string) [][2]string {
+	out := [][2]string{
+		{"MESSAGE", message},
+		{"PRIORITY", fmt.Sprint(priority)},
+		{"SYSLOG_IDENTIFIER", "komari-agent"},
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_':
+				return r
+			default:
+				return '_'
+			}
+		}, k)
+		name = strings.TrimLeft(name, "_")
+		if name == "" || fields[k] == "" {
+			continue
+		}
+		out = append(out, [2]string{name, fields[k]})
+	}
+	return out
+}
@@ -18,9 +18,16 @@ func GenerateReport() []byte {
 	if cpuUsage <= 0.001 {
 		cpuUsage = 0.001
 	}
-	data["cpu"] = map[string]interface{}{
+	cpuData := map[string]interface{}{
 		"usage": cpuUsage,
 	}
+	if cpu.Breakdown != nil {
+		cpuData["breakdown"] = cpu.Breakdown
+	}
+	if len(cpu.Cores) > 0 {
+		cpuData["cores"] = cpu.Cores
+	}
+	data["cpu"] = cpuData
 
 	ram := monitoring.Ram()
 	data["ram"] = map[string]interface{}{
@@ -39,12 +46,22 @@ func GenerateReport() []byte {
 		"load5":  load.Load5,
 		"load15": load.Load15,
 	}
+	if pressure, ok := monitoring.Pressure(); ok {
+		data["pressure"] = pressure
+	}
 
 	disk := monitoring.Disk()
-	data["disk"] = map[string]interface{}{
+	diskData := map[string]interface{}{
 		"total": disk.Total,
 		"used":  disk.Used,
 	}
+	if len(disk.Pools) > 0 {
+		diskData["pools"] = disk.Pools
+	}
+	if len(disk.Mounts) > 0 {
+		diskData["mounts"] = disk.Mounts
+	}
+	data["disk"] = diskData
 
 	totalUp, totalDown, networkUp, networkDown, err := monitoring.NetworkSpeed()
 	if err != nil {
@@ -57,14 +74,18 @@ func GenerateReport() []byte {
 		"totalDown": totalDown,
 	}
 
-	tcpCount, udpCount, err := monitoring.ConnectionsCount()
+	quality, err := monitoring.NetworkQualityStats()
 	if err != nil {
-		message += fmt.Sprintf("failed to get connections: %v\n", err)
+		message += fmt.Sprintf("failed to get network quality: %v\n", err)
+	} else {
+		data["network_quality"] = quality
 	}
-	data["connections"] = map[string]interface{}{
-		"tcp": tcpCount,
-		"udp": udpCount,
+
+	connections, err := monitoring.Connections()
+	if err != nil {
+		message += fmt.Sprintf("failed to get connections: %v\n", err)
 	}
+	data["connections"] = connections
 
 	uptime, err := monitoring.Uptime()
 	if err != nil {
@@ -75,6 +96,47 @@ func GenerateReport() []byte {
 	processcount := monitoring.ProcessCount()
 	data["process"] = processcount
 
+	if flags.ProcessTopN > 0 {
+		byCPU, byMem, err := monitoring.TopProcesses(flags.ProcessTopN)
+		if err != nil {
+			message += fmt.Sprintf("failed to get top processes: %v\n", err)
+		} else {
+			data["process_top"] = map[string]interface{}{
+				"cpu":    byCPU,
+				"memory": byMem,
+			}
+		}
+	}
+
+	if flags.CgroupAware {
+		if cg, ok := monitoring.CgroupStats(); ok {
+			data["cgroup"] = cg
+		}
+	}
+
+	if flags.EnableContainers {
+		containers, err := monitoring.Containers()
+		if err != nil {
+			message += fmt.Sprintf("failed to get containers: %v\n", err)
+		} else {
+			data["containers"] = containers
+		}
+	}
+
+	if failedUnits, ok := monitoring.FailedUnits(); ok {
+		data["systemd"] = map[string]interface{}{
+			"failed_units": failedUnits,
+		}
+	}
+
+	if watchdog := monitoring.WatchdogStatus(); len(watchdog) > 0 {
+		data["watchdog"] = watchdog
+	}
+
+	if quota := monitoring.TrafficQuotaStatus(); len(quota) > 0 {
+		data["traffic_quota"] = quota
+	}
+
 	// GPU监控 - 根据标志决定详细程度
 	if flags.EnableGPU {
 		// 详细GPU监控模式
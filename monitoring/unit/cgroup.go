@@ -0,0 +1,17 @@
+package monitoring
+
+// CgroupInfo cgroup 的资源限制与使用情况，限制为 0 表示不限制
+type CgroupInfo struct {
+	Version             int     `json:"version"`
+	Path                string  `json:"path"`
+	MemoryLimit         uint64  `json:"memory_limit"`
+	MemoryUsage         uint64  `json:"memory_usage"`
+	MemoryWorkingSet    uint64  `json:"memory_working_set"` // 扣除 inactive_file 后的内存，与 docker stats 一致
+	CPUQuota            float64 `json:"cpu_quota"`          // 可用核数
+	CPUUsageSeconds     float64 `json:"cpu_usage_seconds"`
+	CPUPeriods          uint64  `json:"cpu_periods"`
+	CPUThrottledPeriods uint64  `json:"cpu_throttled_periods"`
+	CPUThrottledSeconds float64 `json:"cpu_throttled_seconds"`
+	PidsLimit           uint64  `json:"pids_limit"`
+	PidsCurrent         uint64  `json:"pids_current"`
+}
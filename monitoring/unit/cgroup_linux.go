@@ -0,0 +1,257 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+const (
+	cgroupMountPoint = "/sys/fs/cgroup"
+	cgroupSelfFile   = "/proc/self/cgroup"
+	// cgroup v1 中未设置内存限制时 limit_in_bytes 为接近 int64 上限的值
+	cgroupV1UnlimitedThreshold = uint64(1) << 62
+)
+
+var cgroupMissingOnce sync.Once
+
+// CgroupStats 读取 agent 自身（或 --cgroup 指定）cgroup 的资源限制与使用情况
+// 描述的是 agent 自身受到的限制，因此始终从 agent 视角读取，不受 --host-root 影响
+func CgroupStats() (*CgroupInfo, bool) {
+	data, err := os.ReadFile(cgroupSelfFile)
+	if err != nil {
+		return nil, false
+	}
+	info := readCgroupStats(cgroupMountPoint, string(data), flags.CgroupPath)
+	if info == nil && flags.CgroupPath != "" {
+		cgroupMissingOnce.Do(func() {
+			log.Printf("Cgroup %s not found under %s, cgroup-aware reporting is disabled", flags.CgroupPath, cgroupMountPoint)
+		})
+	}
+	return info, info != nil
+}
+
+// readCgroupStats 根据 /proc/self/cgroup 内容定位 cgroup 目录并读取统计；override 非空时使用指定路径，
+// 指定路径不存在时返回 nil，而不是像自动检测那样回退到挂载点根目录（那会把整机的用量当作该 cgroup 上报）
+func readCgroupStats(root, selfCgroup, override string) *CgroupInfo {
+	if override != "" && !cgroupOverrideExists(root, override) {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		path := override
+		if path == "" {
+			path = cgroupPathFor(selfCgroup, "")
+		}
+		return readCgroupV2(root, path)
+	}
+	if _, err := os.Stat(filepath.Join(root, "memory")); err == nil {
+		return readCgroupV1(root, selfCgroup, override)
+	}
+	return nil
+}
+
+// cgroupPathFor 从 /proc/self/cgroup 中取出指定控制器的路径，controller 为空表示 v2 统一层级
+func cgroupPathFor(selfCgroup, controller string) string {
+	scanner := bufio.NewScanner(strings.NewReader(selfCgroup))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if controller == "" {
+			if parts[0] == "0" && parts[1] == "" {
+				return parts[2]
+			}
+			continue
+		}
+		for _, c := range strings.Split(parts[1], ",") {
+			if c == controller {
+				return parts[2]
+			}
+		}
+	}
+	return "/"
+}
+
+// cgroupOverrideExists 检查 --cgroup 指定的路径是否存在，cgroup v1 以 memory 控制器为准
+func cgroupOverrideExists(root, override string) bool {
+	dir := filepath.Join(root, override)
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		dir = filepath.Join(root, "memory", override)
+	}
+	st, err := os.Stat(dir)
+	return err == nil && st.IsDir()
+}
+
+// cgroupDir 拼接 cgroup 目录；容器内未启用 cgroup namespace 时 /proc/self/cgroup 中的路径
+// 在挂载点下并不存在（挂载点本身就是容器的 cgroup），此时回退到挂载点根目录
+func cgroupDir(base, path string) string {
+	dir := filepath.Join(base, path)
+	if st, err := os.Stat(dir); err == nil && st.IsDir() {
+		return dir
+	}
+	return base
+}
+
+func readCgroupV2(root, path string) *CgroupInfo {
+	dir := cgroupDir(root, path)
+	info := &CgroupInfo{Version: 2, Path: path}
+
+	if v, ok := readCgroupLimit(filepath.Join(dir, "memory.max")); ok {
+		info.MemoryLimit = v
+	}
+	info.MemoryUsage = readCgroupUint(filepath.Join(dir, "memory.current"))
+	memStat := readCgroupKeyValues(filepath.Join(dir, "memory.stat"))
+	info.MemoryWorkingSet = workingSet(info.MemoryUsage, memStat["inactive_file"])
+
+	if data, err := os.ReadFile(filepath.Join(dir, "cpu.max")); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 && fields[0] != "max" {
+			quota, err1 := strconv.ParseFloat(fields[0], 64)
+			period, err2 := strconv.ParseFloat(fields[1], 64)
+			if err1 == nil && err2 == nil && period > 0 {
+				info.CPUQuota = quota / period
+			}
+		}
+	}
+	cpuStat := readCgroupKeyValues(filepath.Join(dir, "cpu.stat"))
+	info.CPUUsageSeconds = float64(cpuStat["usage_usec"]) / 1e6
+	info.CPUPeriods = cpuStat["nr_periods"]
+	info.CPUThrottledPeriods = cpuStat["nr_throttled"]
+	info.CPUThrottledSeconds = float64(cpuStat["throttled_usec"]) / 1e6
+
+	if v, ok := readCgroupLimit(filepath.Join(dir, "pids.max")); ok {
+		info.PidsLimit = v
+	}
+	info.PidsCurrent = readCgroupUint(filepath.Join(dir, "pids.current"))
+	return info
+}
+
+func readCgroupV1(root, selfCgroup, override string) *CgroupInfo {
+	info := &CgroupInfo{Version: 1}
+	controllerDir := func(controller string) string {
+		path := override
+		if path == "" {
+			path = cgroupPathFor(selfCgroup, controller)
+		}
+		if controller == "memory" {
+			info.Path = path
+		}
+		return cgroupDir(filepath.Join(root, controller), path)
+	}
+
+	memDir := controllerDir("memory")
+	if limit := readCgroupUint(filepath.Join(memDir, "memory.limit_in_bytes")); limit < cgroupV1UnlimitedThreshold {
+		info.MemoryLimit = limit
+	}
+	info.MemoryUsage = readCgroupUint(filepath.Join(memDir, "memory.usage_in_bytes"))
+	memStat := readCgroupKeyValues(filepath.Join(memDir, "memory.stat"))
+	info.MemoryWorkingSet = workingSet(info.MemoryUsage, memStat["total_inactive_file"])
+
+	cpuDir := controllerDir("cpu")
+	quota, err1 := strconv.ParseInt(readCgroupString(filepath.Join(cpuDir, "cpu.cfs_quota_us")), 10, 64)
+	period, err2 := strconv.ParseInt(readCgroupString(filepath.Join(cpuDir, "cpu.cfs_period_us")), 10, 64)
+	if err1 == nil && err2 == nil && quota > 0 && period > 0 {
+		info.CPUQuota = float64(quota) / float64(period)
+	}
+	cpuStat := readCgroupKeyValues(filepath.Join(cpuDir, "cpu.stat"))
+	info.CPUPeriods = cpuStat["nr_periods"]
+	info.CPUThrottledPeriods = cpuStat["nr_throttled"]
+	info.CPUThrottledSeconds = float64(cpuStat["throttled_time"]) / 1e9
+	info.CPUUsageSeconds = float64(readCgroupUint(filepath.Join(controllerDir("cpuacct"), "cpuacct.usage"))) / 1e9
+
+	pidsDir := controllerDir("pids")
+	if v, ok := readCgroupLimit(filepath.Join(pidsDir, "pids.max")); ok {
+		info.PidsLimit = v
+	}
+	info.PidsCurrent = readCgroupUint(filepath.Join(pidsDir, "pids.current"))
+	return info
+}
+
+// cgroupCPUPercent 在 interval 内采样两次 cgroup CPU 时间，返回相对于配额的使用率；未设置配额时返回 false
+func cgroupCPUPercent(interval time.Duration) (float64, bool) {
+	first, ok := CgroupStats()
+	if !ok || first.CPUQuota <= 0 {
+		return 0, false
+	}
+	start := time.Now()
+	time.Sleep(interval)
+	second, ok := CgroupStats()
+	if !ok {
+		return 0, false
+	}
+	elapsed := time.Since(start).Seconds()
+	used := second.CPUUsageSeconds - first.CPUUsageSeconds
+	if elapsed <= 0 || used < 0 {
+		return 0, false
+	}
+	percent := used / elapsed / first.CPUQuota * 100
+	if percent > 100 {
+		percent = 100
+	}
+	return percent, true
+}
+
+func workingSet(usage, inactiveFile uint64) uint64 {
+	if inactiveFile < usage {
+		return usage - inactiveFile
+	}
+	return usage
+}
+
+func readCgroupString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func readCgroupUint(path string) uint64 {
+	v, _ := strconv.ParseUint(readCgroupString(path), 10, 64)
+	return v
+}
+
+// readCgroupLimit 读取 v2 风格的限制文件，"max" 表示不限制（返回 0）
+func readCgroupLimit(path string) (uint64, bool) {
+	s := readCgroupString(path)
+	if s == "" {
+		return 0, false
+	}
+	if s == "max" {
+		return 0, true
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	return v, err == nil
+}
+
+// readCgroupKeyValues 解析 "key value" 形式的统计文件，如 memory.stat、cpu.stat
+func readCgroupKeyValues(path string) map[string]uint64 {
+	values := map[string]uint64{}
+	file, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values
+}
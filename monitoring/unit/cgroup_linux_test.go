@@ -0,0 +1,97 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCgroupFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadCgroupV2(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFiles(t, root, map[string]string{
+		"cgroup.controllers":              "cpu memory pids\n",
+		"system.slice/app/memory.max":     "1073741824\n",
+		"system.slice/app/memory.current": "536870912\n",
+		"system.slice/app/memory.stat":    "anon 100\ninactive_file 134217728\n",
+		"system.slice/app/cpu.max":        "150000 100000\n",
+		"system.slice/app/cpu.stat":       "usage_usec 2500000\nnr_periods 10\nnr_throttled 4\nthrottled_usec 500000\n",
+		"system.slice/app/pids.max":       "max\n",
+		"system.slice/app/pids.current":   "12\n",
+	})
+
+	info := readCgroupStats(root, "0::/system.slice/app\n", "")
+	if info == nil || info.Version != 2 || info.Path != "/system.slice/app" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.MemoryLimit != 1<<30 || info.MemoryUsage != 1<<29 || info.MemoryWorkingSet != 1<<29-1<<27 {
+		t.Errorf("unexpected memory: %+v", info)
+	}
+	if info.CPUQuota != 1.5 || info.CPUUsageSeconds != 2.5 || info.CPUThrottledPeriods != 4 || info.CPUThrottledSeconds != 0.5 {
+		t.Errorf("unexpected cpu: %+v", info)
+	}
+	if info.PidsLimit != 0 || info.PidsCurrent != 12 {
+		t.Errorf("unexpected pids: %+v", info)
+	}
+}
+
+func TestReadCgroupV1Namespaced(t *testing.T) {
+	root := t.TempDir()
+	// 未启用 cgroup namespace 的容器：/proc/self/cgroup 中的路径在挂载点下不存在
+	writeCgroupFiles(t, root, map[string]string{
+		"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		"memory/memory.usage_in_bytes": "2048\n",
+		"memory/memory.stat":           "total_inactive_file 1024\n",
+		"cpu/cpu.cfs_quota_us":         "-1\n",
+		"cpu/cpu.cfs_period_us":        "100000\n",
+		"cpu/cpu.stat":                 "nr_periods 0\nnr_throttled 0\nthrottled_time 0\n",
+		"cpuacct/cpuacct.usage":        "3000000000\n",
+		"pids/pids.max":                "512\n",
+		"pids/pids.current":            "3\n",
+	})
+
+	info := readCgroupStats(root, "4:memory:/docker/abc\n3:cpu,cpuacct:/docker/abc\n2:pids:/docker/abc\n", "")
+	if info == nil || info.Version != 1 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.MemoryLimit != 0 || info.MemoryUsage != 2048 || info.MemoryWorkingSet != 1024 {
+		t.Errorf("unexpected memory: %+v", info)
+	}
+	if info.CPUQuota != 0 || info.CPUUsageSeconds != 3 {
+		t.Errorf("unexpected cpu: %+v", info)
+	}
+	if info.PidsLimit != 512 || info.PidsCurrent != 3 {
+		t.Errorf("unexpected pids: %+v", info)
+	}
+}
+
+func TestReadCgroupOverrideMissing(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFiles(t, root, map[string]string{
+		"cgroup.controllers":          "cpu memory pids\n",
+		"memory.current":              "8589934592\n",
+		"system.slice/app/memory.max": "1073741824\n",
+	})
+	// 指定路径拼写错误时不能回退到挂载点根目录，把整机用量当作该 cgroup
+	if info := readCgroupStats(root, "0::/\n", "/system.slice/ap"); info != nil {
+		t.Errorf("expected nil for missing override, got %+v", info)
+	}
+	if info := readCgroupStats(root, "0::/\n", "/system.slice/app"); info == nil || info.MemoryLimit != 1<<30 {
+		t.Errorf("unexpected info for existing override: %+v", info)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+import "time"
+
+// CgroupStats 非 Linux 平台没有 cgroup
+func CgroupStats() (*CgroupInfo, bool) {
+	return nil, false
+}
+
+func cgroupCPUPercent(interval time.Duration) (float64, bool) {
+	return 0, false
+}
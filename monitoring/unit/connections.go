@@ -0,0 +1,78 @@
+package monitoring
+
+import (
+	"net"
+	"sort"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// ConnectionStats TCP/UDP 套接字统计，TCP 按状态细分
+type ConnectionStats struct {
+	TCP       int            `json:"tcp"`
+	UDP       int            `json:"udp"`
+	TCPStates map[string]int `json:"tcp_states"` // ESTABLISHED / TIME_WAIT / SYN_RECV / LISTEN ...
+	TCPListen int            `json:"tcp_listen"`
+	UDPListen int            `json:"udp_listen"` // 未连接（只绑定本地端口）的 UDP 套接字
+	TopPeers  []PeerCount    `json:"top_peers,omitempty"`
+}
+
+// PeerCount 单个远端 IP 的 TCP 连接数
+type PeerCount struct {
+	IP    string `json:"ip"`
+	Count int    `json:"count"`
+}
+
+// socketEntry 单个套接字的协议、状态与远端地址
+type socketEntry struct {
+	proto  string // tcp / udp
+	state  string
+	remote net.IP
+}
+
+// Connections 统计各状态的套接字数量，--connection-peers 大于 0 时附带连接数最多的远端 IP
+func Connections() (ConnectionStats, error) {
+	entries, err := socketEntries()
+	if err != nil {
+		return ConnectionStats{TCPStates: map[string]int{}}, err
+	}
+	return tallyConnections(entries, flags.ConnectionPeers), nil
+}
+
+func tallyConnections(entries []socketEntry, topPeers int) ConnectionStats {
+	stats := ConnectionStats{TCPStates: map[string]int{}}
+	peers := map[string]int{}
+	for _, e := range entries {
+		if e.proto == "udp" {
+			stats.UDP++
+			if e.remote == nil || e.remote.IsUnspecified() {
+				stats.UDPListen++
+			}
+			continue
+		}
+		stats.TCP++
+		stats.TCPStates[e.state]++
+		if e.state == "LISTEN" {
+			stats.TCPListen++
+			continue
+		}
+		if topPeers > 0 && e.remote != nil && !e.remote.IsUnspecified() && !e.remote.IsLoopback() {
+			peers[e.remote.String()]++
+		}
+	}
+	if topPeers > 0 && len(peers) > 0 {
+		for ip, n := range peers {
+			stats.TopPeers = append(stats.TopPeers, PeerCount{IP: ip, Count: n})
+		}
+		sort.Slice(stats.TopPeers, func(i, j int) bool {
+			if stats.TopPeers[i].Count != stats.TopPeers[j].Count {
+				return stats.TopPeers[i].Count > stats.TopPeers[j].Count
+			}
+			return stats.TopPeers[i].IP < stats.TopPeers[j].IP
+		})
+		if len(stats.TopPeers) > topPeers {
+			stats.TopPeers = stats.TopPeers[:topPeers]
+		}
+	}
+	return stats
+}
@@ -0,0 +1,85 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// tcpStates 内核 include/net/tcp_states.h 中的状态编号
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+	"0C": "NEW_SYN_RECV",
+}
+
+// socketEntries 直接读取 /proc/net/{tcp,tcp6,udp,udp6}，不像 gopsutil 那样遍历每个进程的文件描述符
+func socketEntries() ([]socketEntry, error) {
+	var entries []socketEntry
+	for _, table := range []struct{ file, proto string }{
+		{"tcp", "tcp"}, {"tcp6", "tcp"}, {"udp", "udp"}, {"udp6", "udp"},
+	} {
+		f, err := os.Open(hostPath("proc", "net", table.file))
+		if err != nil {
+			if os.IsNotExist(err) && strings.HasSuffix(table.file, "6") {
+				continue // 内核未启用 IPv6
+			}
+			return nil, fmt.Errorf("failed to read %s sockets: %w", table.file, err)
+		}
+		entries, err = parseProcNetSockets(f, table.proto, entries)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s sockets: %w", table.file, err)
+		}
+	}
+	return entries, nil
+}
+
+// parseProcNetSockets 解析 /proc/net/tcp 格式的套接字表，追加到 entries
+func parseProcNetSockets(r io.Reader, proto string, entries []socketEntry) ([]socketEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // 表头
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		e := socketEntry{proto: proto, state: tcpStates[fields[3]]}
+		if e.state == "" {
+			e.state = fields[3]
+		}
+		if host, _, ok := strings.Cut(fields[2], ":"); ok {
+			e.remote = parseProcNetIP(host)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// parseProcNetIP 地址按 32 位字以主机字节序（小端）输出
+func parseProcNetIP(s string) net.IP {
+	b, err := hex.DecodeString(s)
+	if err != nil || (len(b) != net.IPv4len && len(b) != net.IPv6len) {
+		return nil
+	}
+	for i := 0; i < len(b); i += 4 {
+		b[i], b[i+1], b[i+2], b[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+	}
+	return net.IP(b)
+}
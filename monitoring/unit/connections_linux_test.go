@@ -0,0 +1,44 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseProcNetSockets(t *testing.T) {
+	tcp := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 12345 1 0000000000000000 100 0 0 10 0
+   1: 0200000A:0016 0100000A:D2F0 01 00000000:00000000 02:000A7D8C 00000000     0        0 23456 4 0000000000000000 20 4 30 10 -1
+   2: 0200000A:C350 71007BCB:01BB 06 00000000:00000000 03:00001770 00000000     0        0 0 3 0000000000000000
+`
+	tcp6 := `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0050 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 3456 1 0000000000000000 100 0 0 10 0
+   1: B80D0120000000000000000001000000:0050 B80D0120000000000000000002000000:C000 03 00000000:00000000 00:00000000 00000000     0        0 0 1 0000000000000000
+`
+	entries, err := parseProcNetSockets(strings.NewReader(tcp), "tcp", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err = parseProcNetSockets(strings.NewReader(tcp6), "tcp", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("entries = %d, want 5", len(entries))
+	}
+	expected := []struct{ state, remote string }{
+		{"LISTEN", "0.0.0.0"},
+		{"ESTABLISHED", "10.0.0.1"},
+		{"TIME_WAIT", "203.123.0.113"},
+		{"LISTEN", "::"},
+		{"SYN_RECV", "2001:db8::2"},
+	}
+	for i, e := range expected {
+		if entries[i].state != e.state || entries[i].remote.String() != e.remote {
+			t.Errorf("entry %d = %s %s, want %s %s", i, entries[i].state, entries[i].remote, e.state, e.remote)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+import (
+	"fmt"
+	"net"
+
+	gnet "github.com/shirou/gopsutil/v4/net"
+)
+
+// socketEntries 非 Linux 平台通过 gopsutil 枚举套接字
+func socketEntries() ([]socketEntry, error) {
+	var entries []socketEntry
+	for _, proto := range []string{"tcp", "udp"} {
+		conns, err := gnet.Connections(proto)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s connections: %w", proto, err)
+		}
+		for _, c := range conns {
+			entries = append(entries, socketEntry{proto: proto, state: c.Status, remote: net.ParseIP(c.Raddr.IP)})
+		}
+	}
+	return entries, nil
+}
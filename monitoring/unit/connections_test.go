@@ -0,0 +1,42 @@
+package monitoring
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestTallyConnections(t *testing.T) {
+	peerA, peerB := net.ParseIP("203.0.113.1"), net.ParseIP("2001:db8::2")
+	entries := []socketEntry{
+		{proto: "tcp", state: "LISTEN", remote: net.IPv4zero},
+		{proto: "tcp", state: "ESTABLISHED", remote: peerA},
+		{proto: "tcp", state: "ESTABLISHED", remote: peerA},
+		{proto: "tcp", state: "TIME_WAIT", remote: peerB},
+		{proto: "tcp", state: "ESTABLISHED", remote: net.ParseIP("127.0.0.1")},
+		{proto: "udp", state: "CLOSE", remote: net.IPv6unspecified},
+		{proto: "udp", state: "ESTABLISHED", remote: peerA},
+	}
+
+	stats := tallyConnections(entries, 0)
+	if stats.TCP != 5 || stats.UDP != 2 || stats.TCPListen != 1 || stats.UDPListen != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	expected := map[string]int{"LISTEN": 1, "ESTABLISHED": 3, "TIME_WAIT": 1}
+	if !reflect.DeepEqual(stats.TCPStates, expected) {
+		t.Errorf("tcp states = %v, want %v", stats.TCPStates, expected)
+	}
+	if stats.TopPeers != nil {
+		t.Errorf("top peers should be disabled: %v", stats.TopPeers)
+	}
+
+	// 回环地址与监听套接字不计入远端统计
+	stats = tallyConnections(entries, 1)
+	if len(stats.TopPeers) != 1 || stats.TopPeers[0] != (PeerCount{IP: "203.0.113.1", Count: 2}) {
+		t.Errorf("top peers = %+v", stats.TopPeers)
+	}
+	stats = tallyConnections(entries, 5)
+	if len(stats.TopPeers) != 2 || stats.TopPeers[1].IP != "2001:db8::2" {
+		t.Errorf("top peers = %+v", stats.TopPeers)
+	}
+}
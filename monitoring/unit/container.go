@@ -0,0 +1,257 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// ContainerInfo 单个容器的状态与资源占用，CPU 为占用单核的百分比
+type ContainerInfo struct {
+	ID           string  `json:"id"`
+	Name         string  `json:"name"`
+	Image        string  `json:"image"`
+	State        string  `json:"state"`
+	Status       string  `json:"status"`
+	RestartCount int     `json:"restart_count"`
+	CPU          float64 `json:"cpu"`
+	MemoryUsage  uint64  `json:"memory_usage"`
+	MemoryLimit  uint64  `json:"memory_limit"`
+	NetRx        uint64  `json:"net_rx"`
+	NetTx        uint64  `json:"net_tx"`
+}
+
+const (
+	// 每个容器都需要单独请求 stats，按此间隔缓存
+	containerCacheTTL      = 10 * time.Second
+	containerAPITimeout    = 5 * time.Second
+	containerStatsParallel = 8
+)
+
+var (
+	containerMu       sync.Mutex
+	containerCache    []ContainerInfo
+	containerCachedAt time.Time
+	containerPrevCPU  = map[string]containerCPUSample{}
+	// 同一套接字复用一个客户端，避免每次刷新新建 Transport 遗留空闲连接
+	engineClient       *http.Client
+	engineClientSocket string
+)
+
+// containerCPUSample 上一次采样的 CPU 计数，用于 one-shot 模式下计算使用率
+type containerCPUSample struct {
+	total  uint64
+	system uint64
+}
+
+// Engine API 响应中用到的字段
+type engineContainer struct {
+	ID     string   `json:"Id"`
+	Names  []string `json:"Names"`
+	Image  string   `json:"Image"`
+	State  string   `json:"State"`
+	Status string   `json:"Status"`
+}
+
+type engineInspect struct {
+	RestartCount int `json:"RestartCount"`
+}
+
+type engineCPUStats struct {
+	CPUUsage struct {
+		TotalUsage  uint64   `json:"total_usage"`
+		PercpuUsage []uint64 `json:"percpu_usage"`
+	} `json:"cpu_usage"`
+	SystemCPUUsage uint64 `json:"system_cpu_usage"`
+	OnlineCPUs     int    `json:"online_cpus"`
+}
+
+type engineStats struct {
+	CPUStats    engineCPUStats `json:"cpu_stats"`
+	PreCPUStats engineCPUStats `json:"precpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+}
+
+// ContainerSocketPath 返回 --container-socket 指定的路径，未指定时探测 Docker/Podman 默认套接字
+func ContainerSocketPath() string {
+	if flags.ContainerSocket != "" {
+		return flags.ContainerSocket
+	}
+	candidates := []string{
+		hostPath("var/run/docker.sock"),
+		hostPath("run/podman/podman.sock"),
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		candidates = append(candidates, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	for _, p := range candidates {
+		if st, err := os.Stat(p); err == nil && st.Mode()&os.ModeSocket != 0 {
+			return p
+		}
+	}
+	return ""
+}
+
+// Containers 通过 Docker/Podman Engine API 列出主机上的容器及其资源占用
+func Containers() ([]ContainerInfo, error) {
+	containerMu.Lock()
+	defer containerMu.Unlock()
+	if containerCache != nil && time.Since(containerCachedAt) < containerCacheTTL {
+		return containerCache, nil
+	}
+
+	socket := ContainerSocketPath()
+	if socket == "" {
+		return nil, fmt.Errorf("no docker or podman socket found")
+	}
+	if engineClient == nil || engineClientSocket != socket {
+		if engineClient != nil {
+			engineClient.CloseIdleConnections()
+		}
+		engineClient, engineClientSocket = newEngineClient(socket), socket
+	}
+	containers, err := listContainers(engineClient)
+	if err != nil {
+		return nil, err
+	}
+	containerCache = containers
+	containerCachedAt = time.Now()
+	return containers, nil
+}
+
+func newEngineClient(socket string) *http.Client {
+	return &http.Client{
+		Timeout: containerAPITimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+			MaxIdleConns: containerStatsParallel,
+		},
+	}
+}
+
+func engineGet(client *http.Client, path string, v interface{}) error {
+	// 主机名仅用于构造 URL，实际连接走 Unix 套接字
+	resp, err := client.Get("http://engine" + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("engine API %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// listContainers 调用方需持有 containerMu
+func listContainers(client *http.Client) ([]ContainerInfo, error) {
+	var list []engineContainer
+	if err := engineGet(client, "/containers/json?all=1", &list); err != nil {
+		return nil, err
+	}
+
+	result := make([]ContainerInfo, len(list))
+	samples := make([]containerCPUSample, len(list))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, containerStatsParallel)
+	for i, c := range list {
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		id := c.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		result[i] = ContainerInfo{ID: id, Name: name, Image: c.Image, State: c.State, Status: c.Status}
+
+		wg.Add(1)
+		go func(i int, fullID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var inspect engineInspect
+			if err := engineGet(client, "/containers/"+fullID+"/json", &inspect); err == nil {
+				result[i].RestartCount = inspect.RestartCount
+			}
+			if result[i].State != "running" {
+				return
+			}
+			var stats engineStats
+			if err := engineGet(client, "/containers/"+fullID+"/stats?stream=false&one-shot=true", &stats); err != nil {
+				return
+			}
+			applyContainerStats(&result[i], &stats, containerPrevCPU[fullID])
+			samples[i] = containerCPUSample{total: stats.CPUStats.CPUUsage.TotalUsage, system: stats.CPUStats.SystemCPUUsage}
+		}(i, c.ID)
+	}
+	wg.Wait()
+
+	prev := make(map[string]containerCPUSample, len(list))
+	for i, c := range list {
+		if samples[i].system > 0 {
+			prev[c.ID] = samples[i]
+		}
+	}
+	containerPrevCPU = prev
+	return result, nil
+}
+
+// applyContainerStats 计算方式与 docker stats 一致：内存扣除 inactive_file，CPU 以系统 CPU 时间为基准
+func applyContainerStats(info *ContainerInfo, stats *engineStats, prev containerCPUSample) {
+	mem := stats.MemoryStats.Usage
+	inactive, ok := stats.MemoryStats.Stats["inactive_file"] // cgroup v2
+	if !ok {
+		inactive = stats.MemoryStats.Stats["total_inactive_file"] // cgroup v1
+	}
+	if inactive < mem {
+		mem -= inactive
+	}
+	info.MemoryUsage = mem
+	info.MemoryLimit = stats.MemoryStats.Limit
+
+	for _, n := range stats.Networks {
+		info.NetRx += n.RxBytes
+		info.NetTx += n.TxBytes
+	}
+
+	// 非 one-shot 模式下 precpu_stats 有效，否则使用本地缓存的上一次采样
+	base := containerCPUSample{total: stats.PreCPUStats.CPUUsage.TotalUsage, system: stats.PreCPUStats.SystemCPUUsage}
+	if base.system == 0 {
+		base = prev
+	}
+	cur := stats.CPUStats
+	if base.system == 0 || cur.SystemCPUUsage <= base.system || cur.CPUUsage.TotalUsage < base.total {
+		return
+	}
+	cpus := cur.OnlineCPUs
+	if cpus == 0 {
+		cpus = len(cur.CPUUsage.PercpuUsage)
+	}
+	if cpus == 0 {
+		cpus = 1
+	}
+	cpuDelta := float64(cur.CPUUsage.TotalUsage - base.total)
+	systemDelta := float64(cur.SystemCPUUsage - base.system)
+	info.CPU = cpuDelta / systemDelta * float64(cpus) * 100
+}
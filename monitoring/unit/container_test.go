@@ -0,0 +1,77 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// newFakeEngine 在临时 Unix 套接字上模拟 Docker Engine API
+func newFakeEngine(t *testing.T) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"Id": "0123456789abcdef0123", "Names": []string{"/web"}, "Image": "nginx:latest", "State": "running", "Status": "Up 2 hours"},
+			{"Id": "fedcba9876543210fedc", "Names": []string{"/job"}, "Image": "busybox", "State": "exited", "Status": "Exited (0)"},
+		})
+	})
+	mux.HandleFunc("/containers/0123456789abcdef0123/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"RestartCount": 3}`))
+	})
+	mux.HandleFunc("/containers/fedcba9876543210fedc/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"RestartCount": 0}`))
+	})
+	mux.HandleFunc("/containers/0123456789abcdef0123/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"cpu_stats": {"cpu_usage": {"total_usage": 3000}, "system_cpu_usage": 20000, "online_cpus": 2},
+			"precpu_stats": {"cpu_usage": {"total_usage": 1000}, "system_cpu_usage": 10000},
+			"memory_stats": {"usage": 1000, "limit": 4000, "stats": {"inactive_file": 200}},
+			"networks": {"eth0": {"rx_bytes": 10, "tx_bytes": 20}, "eth1": {"rx_bytes": 1, "tx_bytes": 2}}
+		}`))
+	})
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return socket
+}
+
+func TestListContainers(t *testing.T) {
+	socket := newFakeEngine(t)
+	containerMu.Lock()
+	defer containerMu.Unlock()
+
+	containers, err := listContainers(newEngineClient(socket))
+	if err != nil {
+		t.Fatalf("listContainers failed: %v", err)
+	}
+	if len(containers) != 2 {
+		t.Fatalf("expected 2 containers, got %d", len(containers))
+	}
+	web := containers[0]
+	if web.ID != "0123456789ab" || web.Name != "web" || web.Image != "nginx:latest" || web.RestartCount != 3 {
+		t.Errorf("unexpected container info: %+v", web)
+	}
+	if web.MemoryUsage != 800 || web.MemoryLimit != 4000 {
+		t.Errorf("unexpected memory: usage=%d limit=%d", web.MemoryUsage, web.MemoryLimit)
+	}
+	if web.NetRx != 11 || web.NetTx != 22 {
+		t.Errorf("unexpected network: rx=%d tx=%d", web.NetRx, web.NetTx)
+	}
+	// (3000-1000)/(20000-10000) * 2 CPUs * 100
+	if web.CPU != 40 {
+		t.Errorf("CPU = %v, want 40", web.CPU)
+	}
+	if job := containers[1]; job.State != "exited" || job.CPU != 0 || job.MemoryUsage != 0 {
+		t.Errorf("unexpected stopped container: %+v", job)
+	}
+}
@@ -2,12 +2,14 @@ package monitoring
 
 import (
 	"bufio"
+	"math"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/komari-monitor/komari-agent/cmd/flags"
 	"github.com/shirou/gopsutil/v4/cpu"
 )
 
@@ -16,6 +18,22 @@ type CpuInfo struct {
 	CPUArchitecture string  `json:"cpu_architecture"`
 	CPUCores        int     `json:"cpu_cores"`
 	CPUUsage        float64 `json:"cpu_usage"`
+	// 主机 CPU 时间按类型细分，按 cgroup 配额计算使用率时仍为整机数据
+	Breakdown *CPUBreakdown `json:"breakdown,omitempty"`
+	// 每个逻辑核心的使用率与频率，需开启 --per-core-cpu
+	Cores []CoreInfo `json:"cores,omitempty"`
+}
+
+// CPUBreakdown 采样间隔内各类 CPU 时间占比（百分比），steal 为被宿主机挪用的时间
+type CPUBreakdown struct {
+	User    float64 `json:"user"`
+	Nice    float64 `json:"nice"`
+	System  float64 `json:"system"`
+	Iowait  float64 `json:"iowait"`
+	Irq     float64 `json:"irq"`
+	Softirq float64 `json:"softirq"`
+	Steal   float64 `json:"steal"`
+	Idle    float64 `json:"idle"`
 }
 
 func Cpu() CpuInfo {
@@ -55,14 +73,82 @@ func Cpu() CpuInfo {
 		cpuinfo.CPUCores = cores
 	}
 
-	percentages, err := cpu.Percent(1*time.Second, false)
-	if err == nil && len(percentages) > 0 {
-		cpuinfo.CPUUsage = percentages[0]
+	// 两次采样 CPU 时间，同时得到总使用率和细分；开启 --per-core-cpu 时在同一间隔内采样每个核心
+	var p1, p2 []cpu.TimesStat
+	if flags.PerCoreCPU {
+		p1, _ = cpu.Times(true)
+	}
+	t1, err := cpu.Times(false)
+	if err != nil || len(t1) == 0 {
+		return cpuinfo
+	}
+	start := time.Now()
+	// 设置了 CPU 配额时，使用率以配额为基准；cgroupCPUPercent 自身等待采样间隔，主机 CPU 时间细分仍照常计算
+	cgroupPercent, cgroupOK := 0.0, false
+	if flags.CgroupAware {
+		cgroupPercent, cgroupOK = cgroupCPUPercent(1 * time.Second)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		time.Sleep(time.Second - elapsed)
+	}
+	if flags.PerCoreCPU {
+		p2, _ = cpu.Times(true)
+	}
+	t2, err := cpu.Times(false)
+	if err != nil || len(t2) == 0 {
+		if cgroupOK {
+			cpuinfo.CPUUsage = cgroupPercent
+		}
+		return cpuinfo
+	}
+	breakdown, usage := cpuBreakdown(t1[0], t2[0])
+	cpuinfo.CPUUsage = usage
+	if cgroupOK {
+		cpuinfo.CPUUsage = cgroupPercent
+	}
+	cpuinfo.Breakdown = &breakdown
+	if flags.PerCoreCPU {
+		cpuinfo.Cores = coreInfos(p1, p2)
 	}
 
 	return cpuinfo
 }
 
+// cpuBreakdown 计算两次采样之间的各类时间占比；使用率与 cpu.Percent 一致，为除 idle 和 iowait 之外的时间
+func cpuBreakdown(t1, t2 cpu.TimesStat) (CPUBreakdown, float64) {
+	user := func(t cpu.TimesStat) float64 {
+		if runtime.GOOS == "linux" {
+			return t.User - t.Guest // Linux 的 user/nice 已包含 guest/guest_nice
+		}
+		return t.User
+	}
+	nice := func(t cpu.TimesStat) float64 {
+		if runtime.GOOS == "linux" {
+			return t.Nice - t.GuestNice
+		}
+		return t.Nice
+	}
+	d := CPUBreakdown{
+		User:    user(t2) - user(t1),
+		Nice:    nice(t2) - nice(t1),
+		System:  t2.System - t1.System,
+		Iowait:  t2.Iowait - t1.Iowait,
+		Irq:     t2.Irq - t1.Irq,
+		Softirq: t2.Softirq - t1.Softirq,
+		Steal:   t2.Steal - t1.Steal,
+		Idle:    t2.Idle - t1.Idle,
+	}
+	total := d.User + d.Nice + d.System + d.Iowait + d.Irq + d.Softirq + d.Steal + d.Idle
+	if total <= 0 {
+		return CPUBreakdown{}, 0
+	}
+	for _, v := range []*float64{&d.User, &d.Nice, &d.System, &d.Iowait, &d.Irq, &d.Softirq, &d.Steal, &d.Idle} {
+		*v = math.Max(0, math.Min(100, *v/total*100))
+	}
+	usage := math.Max(0, math.Min(100, 100-d.Idle-d.Iowait))
+	return d, usage
+}
+
 // readCPUNameFromLscpu 从 lscpu 命令读取 CPU 名称
 func readCPUNameFromLscpu() (string, error) {
 	cmd := exec.Command("lscpu")
@@ -87,7 +173,7 @@ func readCPUNameFromLscpu() (string, error) {
 
 // readCPUNameFromProc 从 /proc/cpuinfo 读取 CPU 名称
 func readCPUNameFromProc() (string, error) {
-	file, err := os.Open("/proc/cpuinfo")
+	file, err := os.Open(hostPath("proc/cpuinfo"))
 	if err != nil {
 		return "", err
 	}
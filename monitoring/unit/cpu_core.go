@@ -0,0 +1,64 @@
+package monitoring
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+// CoreInfo 单个逻辑核心的使用率、频率（MHz）与温控降频情况
+type CoreInfo struct {
+	Core          int     `json:"core"`
+	Usage         float64 `json:"usage"`
+	Freq          float64 `json:"freq,omitempty"`
+	MinFreq       float64 `json:"min_freq,omitempty"`
+	MaxFreq       float64 `json:"max_freq,omitempty"`
+	ThrottleCount uint64  `json:"throttle_count,omitempty"` // 开机以来的温控降频次数
+	Throttled     bool    `json:"throttled"`                // 自上次采样以来发生过降频
+}
+
+var (
+	throttleMu   sync.Mutex
+	throttleLast = map[int]uint64{}
+)
+
+// coreInfos 按核心匹配两次采样，计算使用率并附带频率与降频信息
+func coreInfos(t1, t2 []cpu.TimesStat) []CoreInfo {
+	prev := map[string]cpu.TimesStat{}
+	for _, t := range t1 {
+		prev[t.CPU] = t
+	}
+	cores := []CoreInfo{}
+	for _, t := range t2 {
+		p, ok := prev[t.CPU]
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(t.CPU, "cpu"))
+		if err != nil {
+			continue
+		}
+		c := CoreInfo{Core: n}
+		_, c.Usage = cpuBreakdown(p, t)
+		c.Freq, c.MinFreq, c.MaxFreq = coreFrequency(n)
+		if count, ok := coreThrottleCount(n); ok {
+			c.ThrottleCount = count
+			c.Throttled = throttleIncreased(n, count)
+		}
+		cores = append(cores, c)
+	}
+	sort.Slice(cores, func(i, j int) bool { return cores[i].Core < cores[j].Core })
+	return cores
+}
+
+// throttleIncreased 记录核心的降频计数，返回与上一次相比是否增加；首次记录不视为降频
+func throttleIncreased(core int, count uint64) bool {
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+	last, ok := throttleLast[core]
+	throttleLast[core] = count
+	return ok && count > last
+}
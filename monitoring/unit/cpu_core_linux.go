@@ -0,0 +1,45 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readCPUSysfs 读取 /sys/devices/system/cpu/cpuN 下的数值文件
+func readCPUSysfs(core int, elem ...string) (uint64, bool) {
+	path := hostPath(append([]string{"sys", "devices", "system", "cpu", "cpu" + strconv.Itoa(core)}, elem...)...)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	return v, err == nil
+}
+
+// coreFrequency 从 cpufreq 读取当前与硬件最小/最大频率（kHz 转为 MHz），虚拟机中通常不存在
+func coreFrequency(core int) (cur, minFreq, maxFreq float64) {
+	if v, ok := readCPUSysfs(core, "cpufreq", "scaling_cur_freq"); ok {
+		cur = float64(v) / 1000
+	}
+	if v, ok := readCPUSysfs(core, "cpufreq", "cpuinfo_min_freq"); ok {
+		minFreq = float64(v) / 1000
+	}
+	if v, ok := readCPUSysfs(core, "cpufreq", "cpuinfo_max_freq"); ok {
+		maxFreq = float64(v) / 1000
+	}
+	return cur, minFreq, maxFreq
+}
+
+// coreThrottleCount x86 的 thermal_throttle 计数，核心与所在封装的降频次数之和
+func coreThrottleCount(core int) (uint64, bool) {
+	coreCount, ok := readCPUSysfs(core, "thermal_throttle", "core_throttle_count")
+	if !ok {
+		return 0, false
+	}
+	pkgCount, _ := readCPUSysfs(core, "thermal_throttle", "package_throttle_count")
+	return coreCount + pkgCount, true
+}
@@ -0,0 +1,47 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+func TestCoreFrequencyAndThrottle(t *testing.T) {
+	old := flags.HostRoot
+	flags.HostRoot = t.TempDir()
+	defer func() { flags.HostRoot = old }()
+
+	write := func(elem ...string) {
+		path := hostPath(append([]string{"sys", "devices", "system", "cpu"}, elem[:len(elem)-1]...)...)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(elem[len(elem)-1]+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cpu0", "cpufreq", "scaling_cur_freq", "2400000")
+	write("cpu0", "cpufreq", "cpuinfo_min_freq", "800000")
+	write("cpu0", "cpufreq", "cpuinfo_max_freq", "3600000")
+	write("cpu0", "thermal_throttle", "core_throttle_count", "3")
+	write("cpu0", "thermal_throttle", "package_throttle_count", "4")
+
+	if cur, minFreq, maxFreq := coreFrequency(0); cur != 2400 || minFreq != 800 || maxFreq != 3600 {
+		t.Errorf("frequency = %v %v %v", cur, minFreq, maxFreq)
+	}
+	if count, ok := coreThrottleCount(0); !ok || count != 7 {
+		t.Errorf("throttle count = %d, %v", count, ok)
+	}
+	// 虚拟机中通常没有 cpufreq 与 thermal_throttle
+	if cur, _, _ := coreFrequency(1); cur != 0 {
+		t.Errorf("missing cpufreq = %v", cur)
+	}
+	if _, ok := coreThrottleCount(1); ok {
+		t.Error("missing thermal_throttle should not be reported")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+// coreFrequency 非 Linux 平台没有 cpufreq
+func coreFrequency(core int) (cur, minFreq, maxFreq float64) {
+	return 0, 0, 0
+}
+
+func coreThrottleCount(core int) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,51 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+func TestCoreInfos(t *testing.T) {
+	t1 := []cpu.TimesStat{
+		{CPU: "cpu1", User: 10, Idle: 90},
+		{CPU: "cpu0", User: 50, Idle: 50},
+	}
+	t2 := []cpu.TimesStat{
+		{CPU: "cpu0", User: 150, Idle: 50},  // 满载
+		{CPU: "cpu1", User: 20, Idle: 180},  // 10%
+		{CPU: "cpu2", User: 100, Idle: 100}, // 第一次采样中不存在
+	}
+	cores := coreInfos(t1, t2)
+	if len(cores) != 2 {
+		t.Fatalf("cores = %+v", cores)
+	}
+	if cores[0].Core != 0 || cores[0].Usage != 100 {
+		t.Errorf("core 0 = %+v", cores[0])
+	}
+	if cores[1].Core != 1 || cores[1].Usage != 10 {
+		t.Errorf("core 1 = %+v", cores[1])
+	}
+}
+
+func TestThrottleIncreased(t *testing.T) {
+	throttleMu.Lock()
+	saved := throttleLast
+	throttleLast = map[int]uint64{}
+	throttleMu.Unlock()
+	defer func() {
+		throttleMu.Lock()
+		throttleLast = saved
+		throttleMu.Unlock()
+	}()
+
+	if throttleIncreased(0, 5) {
+		t.Error("first sample should not count as throttling")
+	}
+	if throttleIncreased(0, 5) {
+		t.Error("unchanged count should not count as throttling")
+	}
+	if !throttleIncreased(0, 7) {
+		t.Error("increased count should count as throttling")
+	}
+}
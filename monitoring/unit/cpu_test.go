@@ -0,0 +1,52 @@
+package monitoring
+
+import (
+	"math"
+	"testing"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+func TestCPUBreakdown(t *testing.T) {
+	t1 := cpu.TimesStat{User: 100, System: 50, Idle: 800, Iowait: 10, Steal: 5, Irq: 1, Softirq: 2, Nice: 2}
+	t2 := cpu.TimesStat{User: 130, System: 60, Idle: 826, Iowait: 20, Steal: 25, Irq: 3, Softirq: 4, Nice: 2}
+
+	d, usage := cpuBreakdown(t1, t2)
+	// 总计 100 个时间单位，各项差值即百分比
+	expected := CPUBreakdown{User: 30, System: 10, Idle: 26, Iowait: 10, Steal: 20, Irq: 2, Softirq: 2}
+	for name, pair := range map[string][2]float64{
+		"user":    {d.User, expected.User},
+		"system":  {d.System, expected.System},
+		"idle":    {d.Idle, expected.Idle},
+		"iowait":  {d.Iowait, expected.Iowait},
+		"steal":   {d.Steal, expected.Steal},
+		"irq":     {d.Irq, expected.Irq},
+		"softirq": {d.Softirq, expected.Softirq},
+	} {
+		if math.Abs(pair[0]-pair[1]) > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, pair[0], pair[1])
+		}
+	}
+	if math.Abs(usage-64) > 1e-9 {
+		t.Errorf("usage = %v, want 64", usage)
+	}
+
+	if _, usage := cpuBreakdown(t1, t1); usage != 0 {
+		t.Errorf("usage without elapsed time = %v", usage)
+	}
+}
+
+func TestCpuCgroupAwareKeepsBreakdown(t *testing.T) {
+	oldCgroup, oldPerCore := flags.CgroupAware, flags.PerCoreCPU
+	flags.CgroupAware, flags.PerCoreCPU = true, true
+	defer func() { flags.CgroupAware, flags.PerCoreCPU = oldCgroup, oldPerCore }()
+
+	info := Cpu()
+	if info.Breakdown == nil {
+		t.Error("breakdown should be reported with --cgroup-aware")
+	}
+	if len(info.Cores) == 0 {
+		t.Error("per-core usage should be reported with --cgroup-aware")
+	}
+}
@@ -9,8 +9,19 @@ import (
 )
 
 type DiskInfo struct {
-	Total uint64 `json:"total"`
-	Used  uint64 `json:"used"`
+	Total  uint64        `json:"total"`
+	Used   uint64        `json:"used"`
+	Pools  []StoragePool `json:"pools,omitempty"`
+	Mounts []MountStat   `json:"mounts,omitempty"`
+}
+
+// MountStat 单个被监控挂载点的 inode 用量与只读状态
+type MountStat struct {
+	Mountpoint  string `json:"mountpoint"`
+	Fstype      string `json:"fstype"`
+	InodesTotal uint64 `json:"inodes_total"`
+	InodesUsed  uint64 `json:"inodes_used"`
+	ReadOnly    bool   `json:"read_only"`
 }
 
 func Disk() DiskInfo {
@@ -22,42 +33,98 @@ func Disk() DiskInfo {
 	} else {
 		// 如果指定了自定义挂载点，只统计指定的挂载点
 		if flags.IncludeMountpoints != "" {
+			parts := make(map[string]disk.PartitionStat, len(usage))
+			for _, part := range usage {
+				parts[part.Mountpoint] = part
+			}
 			includeMounts := strings.Split(flags.IncludeMountpoints, ";")
 			for _, mountpoint := range includeMounts {
 				mountpoint = strings.TrimSpace(mountpoint)
 				if mountpoint != "" {
-					u, err := disk.Usage(mountpoint)
+					u, err := diskUsage(mountpoint)
 					if err != nil {
 						continue
 					} else {
 						diskinfo.Total += u.Total
 						diskinfo.Used += u.Used
+						diskinfo.Mounts = append(diskinfo.Mounts, mountStat(mountpoint, parts[mountpoint], u))
 					}
 				}
 			}
 		} else {
 			// 使用默认逻辑，排除临时文件系统和网络驱动器
 			for _, part := range usage {
-				if isPhysicalDisk(part) {
-					u, err := disk.Usage(part.Mountpoint)
-					if err != nil {
-						continue
-					} else {
-						diskinfo.Total += u.Total
-						diskinfo.Used += u.Used
-					}
+				if !isPhysicalDisk(part) {
+					continue
+				}
+				u, err := diskUsage(part.Mountpoint)
+				if err != nil {
+					continue
 				}
+				diskinfo.Mounts = append(diskinfo.Mounts, mountStat(part.Mountpoint, part, u))
+				// ZFS/Btrfs 的数据集和子卷共享池空间，按池统计
+				if !isPoolFilesystem(part) {
+					diskinfo.Total += u.Total
+					diskinfo.Used += u.Used
+				}
+			}
+			diskinfo.Pools = StoragePools(usage)
+			for _, pool := range diskinfo.Pools {
+				diskinfo.Total += pool.Size
+				diskinfo.Used += pool.Allocated
 			}
 		}
 	}
 	return diskinfo
 }
 
+func mountStat(mountpoint string, part disk.PartitionStat, u *disk.UsageStat) MountStat {
+	fstype := part.Fstype
+	if fstype == "" {
+		fstype = u.Fstype
+	}
+	readOnly := isReadOnly(part.Opts)
+	// 设置 --host-root 时 statfs 看到的是容器内的绑定挂载（通常为 :ro），只能以宿主机挂载选项为准
+	if !readOnly && !isHostRootSet() {
+		readOnly = statfsReadOnly(u.Path)
+	}
+	return MountStat{
+		Mountpoint:  mountpoint,
+		Fstype:      fstype,
+		InodesTotal: u.InodesTotal,
+		InodesUsed:  u.InodesUsed,
+		ReadOnly:    readOnly,
+	}
+}
+
+// isReadOnly 挂载选项中包含 ro，例如出错后被内核重新挂载为只读
+func isReadOnly(opts []string) bool {
+	for _, opt := range opts {
+		if opt == "ro" {
+			return true
+		}
+	}
+	return false
+}
+
+// diskUsage 统计宿主机挂载点的用量，设置 --host-root 时挂载点位于宿主机根目录之下
+func diskUsage(mountpoint string) (*disk.UsageStat, error) {
+	if isHostRootSet() {
+		mountpoint = hostPath(mountpoint)
+	}
+	return disk.Usage(mountpoint)
+}
+
 // isPhysicalDisk 判断分区是否为物理磁盘
 func isPhysicalDisk(part disk.PartitionStat) bool {
+	return diskExcludeReason(part) == ""
+}
+
+// diskExcludeReason 返回分区被排除的原因，为空表示按物理磁盘统计
+func diskExcludeReason(part disk.PartitionStat) string {
 	// 对于LXC等基于loop的根文件系统，始终包含根挂载点
 	if part.Mountpoint == "/" {
-		return true
+		return ""
 	}
 	mountpoint := strings.ToLower(part.Mountpoint)
 	// 排除挂载点
@@ -81,7 +148,7 @@ func isPhysicalDisk(part disk.PartitionStat) bool {
 	}
 	for _, mp := range mountpointsToExclude {
 		if mountpoint == mp || strings.HasPrefix(mountpoint, mp) {
-			return false
+			return fmt.Sprintf("mountpoint matches excluded prefix %q", mp)
 		}
 	}
 
@@ -105,22 +172,22 @@ func isPhysicalDisk(part disk.PartitionStat) bool {
 	}
 	for _, fs := range fstypeToExclude {
 		if fstype == fs || strings.HasPrefix(fstype, fs) {
-			return false
+			return fmt.Sprintf("fstype matches excluded type %q", fs)
 		}
 	}
 	// Windows 网络驱动器通常是映射盘符，但不容易通过fstype判断
 	// 可以通过opts判断，Windows网络驱动通常有相关选项
 	optsStr := strings.ToLower(strings.Join(part.Opts, ","))
 	if strings.Contains(optsStr, "remote") || strings.Contains(optsStr, "network") {
-		return false
+		return "mount options indicate a network drive"
 	}
 
 	// 虚拟内存
 	if strings.HasPrefix(part.Device, "/dev/loop") {
-		return false
+		return "loop device"
 	}
 
-	return true
+	return ""
 }
 
 func DiskList() ([]string, error) {
@@ -139,10 +206,13 @@ func DiskList() ([]string, error) {
 			return nil, err
 		}
 		for _, part := range usage {
-			if isPhysicalDisk(part) {
+			if isPhysicalDisk(part) && !isPoolFilesystem(part) {
 				diskList = append(diskList, fmt.Sprintf("%s (%s)", part.Mountpoint, part.Fstype))
 			}
 		}
+		for _, pool := range groupStoragePools(usage) {
+			diskList = append(diskList, fmt.Sprintf("%s (%s pool: %s)", pool.Name, pool.Type, strings.Join(pool.Mountpoints, ", ")))
+		}
 	}
 	return diskList, nil
 }
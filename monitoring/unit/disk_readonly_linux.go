@@ -0,0 +1,15 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import "golang.org/x/sys/unix"
+
+// statfsReadOnly 检查超级块的只读标志；因错误被内核重新挂载为只读时，挂载选项中不一定体现
+func statfsReadOnly(path string) bool {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return false
+	}
+	return st.Flags&unix.ST_RDONLY != 0
+}
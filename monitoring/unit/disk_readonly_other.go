@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+func statfsReadOnly(path string) bool {
+	return false
+}
@@ -0,0 +1,49 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+func TestMountStat(t *testing.T) {
+	part := disk.PartitionStat{Mountpoint: "/data", Fstype: "ext4", Opts: []string{"ro", "relatime"}}
+	u := &disk.UsageStat{Path: "/nonexistent", Fstype: "ext2/ext3", InodesTotal: 1000, InodesUsed: 250}
+	m := mountStat("/data", part, u)
+	if m.Fstype != "ext4" || m.InodesTotal != 1000 || m.InodesUsed != 250 || !m.ReadOnly {
+		t.Errorf("unexpected mount stat: %+v", m)
+	}
+
+	// --include-mountpoint 指定的挂载点可能不在分区列表中，此时使用 statfs 得到的类型
+	m = mountStat("/data", disk.PartitionStat{}, u)
+	if m.Fstype != "ext2/ext3" || m.ReadOnly {
+		t.Errorf("unexpected mount stat without partition: %+v", m)
+	}
+}
+
+func TestIsReadOnly(t *testing.T) {
+	if isReadOnly([]string{"rw", "errors=remount-ro"}) {
+		t.Error("errors=remount-ro should not be treated as read-only")
+	}
+	if !isReadOnly([]string{"ro", "bind"}) {
+		t.Error("ro mount not detected")
+	}
+}
+
+func TestDiskExcludeReason(t *testing.T) {
+	tests := []struct {
+		part     disk.PartitionStat
+		expected string
+	}{
+		{disk.PartitionStat{Mountpoint: "/", Device: "/dev/loop0", Fstype: "ext4"}, ""},
+		{disk.PartitionStat{Mountpoint: "/var/lib/docker/overlay2/abc/merged", Fstype: "overlay"}, `mountpoint matches excluded prefix "/var/lib/docker"`},
+		{disk.PartitionStat{Mountpoint: "/mnt/nas", Fstype: "nfs4"}, `fstype matches excluded type "nfs"`},
+		{disk.PartitionStat{Mountpoint: "/snap/core/1", Device: "/dev/loop3", Fstype: "squashfs"}, "loop device"},
+		{disk.PartitionStat{Mountpoint: "/data", Device: "/dev/sdb1", Fstype: "xfs"}, ""},
+	}
+	for _, tt := range tests {
+		if got := diskExcludeReason(tt.part); got != tt.expected {
+			t.Errorf("diskExcludeReason(%s) = %q, want %q", tt.part.Mountpoint, got, tt.expected)
+		}
+	}
+}
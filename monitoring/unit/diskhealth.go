@@ -0,0 +1,381 @@
+package monitoring
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// RaidArray /proc/mdstat 中的一个 md 阵列
+type RaidArray struct {
+	Name          string   `json:"name"`
+	Level         string   `json:"level"`
+	State         string   `json:"state"` // active / degraded / recovering / resync / check / reshape / inactive，事件中还可能为 removed
+	Devices       int      `json:"devices"`
+	ActiveDevices int      `json:"active_devices"`
+	Members       []string `json:"members"`
+	Failed        []string `json:"failed,omitempty"`
+	Spares        []string `json:"spares,omitempty"`
+	Degraded      bool     `json:"degraded"`
+	SyncProgress  float64  `json:"sync_progress,omitempty"` // 同步/重建进度百分比
+}
+
+// SmartDevice smartctl 报告的单块磁盘健康信息
+type SmartDevice struct {
+	Device             string `json:"device"`
+	Type               string `json:"type"`
+	Model              string `json:"model"`
+	Serial             string `json:"serial"`
+	Health             string `json:"health"` // PASSED / FAILED / UNKNOWN
+	Temperature        int    `json:"temperature"`
+	ReallocatedSectors uint64 `json:"reallocated_sectors"`
+	PendingSectors     uint64 `json:"pending_sectors"`
+	MediaErrors        uint64 `json:"media_errors,omitempty"` // NVMe
+	WearUsed           *int   `json:"wear_used,omitempty"`    // 已用寿命百分比，未知时省略
+	PowerOnHours       uint64 `json:"power_on_hours"`
+}
+
+// DiskHealth RAID 阵列与 SMART 磁盘的健康状态
+type DiskHealth struct {
+	Raid  []RaidArray   `json:"raid,omitempty"`
+	Smart []SmartDevice `json:"smart,omitempty"`
+}
+
+const (
+	diskHealthPollInterval = time.Minute
+	// smartctl 会唤醒休眠磁盘，读取间隔明显长于 mdstat
+	smartPollInterval = 30 * time.Minute
+	smartctlTimeout   = 30 * time.Second
+)
+
+var (
+	diskHealthMu     sync.Mutex
+	diskHealthState  DiskHealth
+	diskHealthOnce   sync.Once
+	diskHealthEvents = make(chan DiskHealth, 16)
+	diskHealthPolled bool
+	smartCheckedAt   time.Time
+)
+
+// StartDiskHealth 在 --disk-health 开启时后台轮询 RAID 与 SMART 状态
+func StartDiskHealth() {
+	if !flags.EnableDiskHealth {
+		return
+	}
+	diskHealthOnce.Do(func() {
+		pollDiskHealth()
+		go func() {
+			ticker := time.NewTicker(diskHealthPollInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				pollDiskHealth()
+			}
+		}()
+	})
+}
+
+// DiskHealthStatus 返回最近一次检查的健康状态
+func DiskHealthStatus() DiskHealth {
+	diskHealthMu.Lock()
+	defer diskHealthMu.Unlock()
+	return diskHealthState
+}
+
+// DiskHealthEvents 阵列状态或磁盘健康发生变化时推送变化的条目
+func DiskHealthEvents() <-chan DiskHealth {
+	return diskHealthEvents
+}
+
+func pollDiskHealth() {
+	diskHealthMu.Lock()
+	prev := diskHealthState
+	smartDue := time.Since(smartCheckedAt) >= smartPollInterval
+	diskHealthMu.Unlock()
+
+	cur := DiskHealth{Smart: prev.Smart}
+	if data, err := os.ReadFile(hostPath("proc/mdstat")); err == nil {
+		cur.Raid = parseMdstat(string(data))
+	}
+	if smartDue {
+		devices, err := smartDevices()
+		if err != nil && !errors.Is(err, exec.ErrNotFound) {
+			log.Println("Failed to read SMART data:", err)
+		}
+		cur.Smart = devices
+	}
+
+	diskHealthMu.Lock()
+	diskHealthState = cur
+	if smartDue {
+		smartCheckedAt = time.Now()
+	}
+	first := !diskHealthPolled
+	diskHealthPolled = true
+	diskHealthMu.Unlock()
+
+	if first {
+		return
+	}
+	if changed := diffDiskHealth(prev, cur); len(changed.Raid) > 0 || len(changed.Smart) > 0 {
+		select {
+		case diskHealthEvents <- changed:
+		default:
+			// 通道已满时丢弃，基本信息中仍会携带最新状态
+		}
+	}
+}
+
+// diffDiskHealth 返回状态发生变化的阵列和磁盘：阵列状态或故障成员变化、阵列从 mdstat 中消失、
+// SMART 结论变化、坏扇区增加
+func diffDiskHealth(prev, cur DiskHealth) DiskHealth {
+	var changed DiskHealth
+	oldRaid := map[string]RaidArray{}
+	for _, r := range prev.Raid {
+		oldRaid[r.Name] = r
+	}
+	for _, r := range cur.Raid {
+		old, ok := oldRaid[r.Name]
+		delete(oldRaid, r.Name)
+		if !ok || old.State != r.State || strings.Join(old.Failed, ",") != strings.Join(r.Failed, ",") {
+			changed.Raid = append(changed.Raid, r)
+		}
+	}
+	// 阵列被停止或未能组装时会从 mdstat 中消失，按上次的信息推送 removed
+	for _, r := range prev.Raid {
+		if _, ok := oldRaid[r.Name]; ok {
+			r.State, r.ActiveDevices, r.SyncProgress = "removed", 0, 0
+			changed.Raid = append(changed.Raid, r)
+		}
+	}
+	oldSmart := map[string]SmartDevice{}
+	for _, d := range prev.Smart {
+		oldSmart[d.Device] = d
+	}
+	for _, d := range cur.Smart {
+		old, ok := oldSmart[d.Device]
+		if !ok {
+			continue // 新出现的磁盘不视为告警
+		}
+		if old.Health != d.Health || d.ReallocatedSectors > old.ReallocatedSectors ||
+			d.PendingSectors > old.PendingSectors || d.MediaErrors > old.MediaErrors {
+			changed.Smart = append(changed.Smart, d)
+		}
+	}
+	return changed
+}
+
+var (
+	mdstatArrayLine  = regexp.MustCompile(`^(md\S+)\s*:\s*(.*)$`)
+	mdstatStatusLine = regexp.MustCompile(`\[(\d+)/(\d+)\]\s+\[([U_]+)\]`)
+	mdstatSyncLine   = regexp.MustCompile(`(recovery|resync|reshape|check|repair)\s*=\s*([\d.]+)%`)
+	mdstatMember     = regexp.MustCompile(`^(\S+)\[\d+\](\([A-Z]\))?$`)
+)
+
+// parseMdstat 解析 /proc/mdstat
+func parseMdstat(data string) []RaidArray {
+	var arrays []RaidArray
+	var cur *RaidArray
+	var action string
+	finish := func() {
+		if cur == nil {
+			return
+		}
+		switch {
+		case cur.State == "inactive":
+		case cur.Degraded && action == "recovery":
+			cur.State = "recovering"
+		case cur.Degraded:
+			cur.State = "degraded"
+		case action != "":
+			cur.State = action
+		}
+		arrays = append(arrays, *cur)
+		cur, action = nil, ""
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := mdstatArrayLine.FindStringSubmatch(line); m != nil {
+			finish()
+			cur = &RaidArray{Name: m[1], Members: []string{}}
+			fields := strings.Fields(m[2])
+			if len(fields) > 0 {
+				cur.State = fields[0]
+				fields = fields[1:]
+			}
+			for _, f := range fields {
+				if strings.HasPrefix(f, "(") {
+					continue // (read-only) / (auto-read-only)
+				}
+				mm := mdstatMember.FindStringSubmatch(f)
+				if mm == nil {
+					if cur.Level == "" {
+						cur.Level = f
+					}
+					continue
+				}
+				switch mm[2] {
+				case "(F)":
+					cur.Failed = append(cur.Failed, mm[1])
+				case "(S)":
+					cur.Spares = append(cur.Spares, mm[1])
+				default:
+					cur.Members = append(cur.Members, mm[1])
+				}
+			}
+			if len(cur.Failed) > 0 {
+				cur.Degraded = true
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			finish()
+			continue
+		}
+		if m := mdstatStatusLine.FindStringSubmatch(line); m != nil {
+			cur.Devices, _ = strconv.Atoi(m[1])
+			cur.ActiveDevices, _ = strconv.Atoi(m[2])
+			if cur.ActiveDevices < cur.Devices || strings.Contains(m[3], "_") {
+				cur.Degraded = true
+			}
+		}
+		if m := mdstatSyncLine.FindStringSubmatch(line); m != nil {
+			action = m[1]
+			cur.SyncProgress, _ = strconv.ParseFloat(m[2], 64)
+		}
+	}
+	finish()
+	return arrays
+}
+
+// smartctl --json 输出中用到的字段
+type smartctlScan struct {
+	Devices []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"devices"`
+}
+
+type smartctlOutput struct {
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current int `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	ATASmartAttributes struct {
+		Table []struct {
+			ID    int `json:"id"`
+			Value int `json:"value"`
+			Raw   struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NVMeHealth *struct {
+		PercentageUsed int    `json:"percentage_used"`
+		MediaErrors    uint64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// smartDevices 通过 smartctl --scan 枚举磁盘并逐个读取 SMART 信息
+func smartDevices() ([]SmartDevice, error) {
+	path, err := exec.LookPath("smartctl")
+	if err != nil {
+		return nil, err
+	}
+	out, err := runSmartctl(path, "--scan", "--json")
+	if err != nil {
+		return nil, err
+	}
+	var scan smartctlScan
+	if err := json.Unmarshal(out, &scan); err != nil {
+		return nil, err
+	}
+	devices := []SmartDevice{}
+	for _, d := range scan.Devices {
+		out, err := runSmartctl(path, "--json", "-a", "-d", d.Type, d.Name)
+		if err != nil {
+			continue
+		}
+		dev, err := parseSmartctl(out)
+		if err != nil {
+			continue
+		}
+		dev.Device, dev.Type = d.Name, d.Type
+		devices = append(devices, dev)
+	}
+	return devices, nil
+}
+
+// runSmartctl smartctl 的退出码是位掩码，磁盘存在告警时也会非零，只要有输出就继续解析
+func runSmartctl(path string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), smartctlTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, args...).Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && len(out) > 0) {
+		return nil, err
+	}
+	return out, nil
+}
+
+func parseSmartctl(data []byte) (SmartDevice, error) {
+	var out smartctlOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return SmartDevice{}, err
+	}
+	dev := SmartDevice{
+		Model:        out.ModelName,
+		Serial:       out.SerialNumber,
+		Health:       "UNKNOWN",
+		Temperature:  out.Temperature.Current,
+		PowerOnHours: out.PowerOnTime.Hours,
+	}
+	if out.SmartStatus != nil {
+		if out.SmartStatus.Passed {
+			dev.Health = "PASSED"
+		} else {
+			dev.Health = "FAILED"
+		}
+	}
+	for _, attr := range out.ATASmartAttributes.Table {
+		switch attr.ID {
+		case 5: // Reallocated_Sector_Ct
+			dev.ReallocatedSectors = attr.Raw.Value
+		case 197: // Current_Pending_Sector
+			dev.PendingSectors = attr.Raw.Value
+		case 177, 202, 231, 233: // Wear_Leveling_Count / Percent_Lifetime_Remain / SSD_Life_Left / Media_Wearout_Indicator，归一化值为剩余寿命
+			if dev.WearUsed == nil && attr.Value > 0 && attr.Value <= 100 {
+				used := 100 - attr.Value
+				dev.WearUsed = &used
+			}
+		}
+	}
+	if out.NVMeHealth != nil {
+		used := out.NVMeHealth.PercentageUsed
+		dev.WearUsed = &used
+		dev.MediaErrors = out.NVMeHealth.MediaErrors
+	}
+	return dev, nil
+}
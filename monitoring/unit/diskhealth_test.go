@@ -0,0 +1,137 @@
+package monitoring
+
+import (
+	"reflect"
+	"testing"
+)
+
+const sampleMdstat = `Personalities : [raid1] [raid6] [raid5] [raid4]
+md0 : active raid1 sdb1[1] sda1[0]
+      1953382464 blocks super 1.2 [2/2] [UU]
+      bitmap: 0/15 pages [0KB], 65536KB chunk
+
+md1 : active raid5 sdc1[3](F) sdd1[1] sde1[0] sdf1[4](S)
+      3906764800 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+      [==>..................]  recovery = 12.6% (246709248/1953382400) finish=160.2min speed=177540K/sec
+
+md2 : active (auto-read-only) raid1 sdg1[0] sdh1[1]
+      976630464 blocks super 1.2 [2/2] [UU]
+      [=====>...............]  resync = 28.1% (274876672/976630464) finish=60.0min speed=194852K/sec
+
+md3 : inactive sdi1[0](S)
+      976630464 blocks super 1.2
+
+unused devices: <none>
+`
+
+func TestParseMdstat(t *testing.T) {
+	arrays := parseMdstat(sampleMdstat)
+	if len(arrays) != 4 {
+		t.Fatalf("expected 4 arrays, got %d: %+v", len(arrays), arrays)
+	}
+
+	md0 := arrays[0]
+	if md0.Name != "md0" || md0.Level != "raid1" || md0.State != "active" || md0.Degraded {
+		t.Errorf("unexpected md0: %+v", md0)
+	}
+	if !reflect.DeepEqual(md0.Members, []string{"sdb1", "sda1"}) || md0.Devices != 2 || md0.ActiveDevices != 2 {
+		t.Errorf("unexpected md0 members: %+v", md0)
+	}
+
+	md1 := arrays[1]
+	if md1.State != "recovering" || !md1.Degraded || md1.SyncProgress != 12.6 {
+		t.Errorf("unexpected md1: %+v", md1)
+	}
+	if !reflect.DeepEqual(md1.Failed, []string{"sdc1"}) || !reflect.DeepEqual(md1.Spares, []string{"sdf1"}) {
+		t.Errorf("unexpected md1 failed/spares: %+v", md1)
+	}
+
+	if md2 := arrays[2]; md2.Level != "raid1" || md2.State != "resync" || md2.Degraded {
+		t.Errorf("unexpected md2: %+v", md2)
+	}
+	if md3 := arrays[3]; md3.State != "inactive" || md3.Level != "" {
+		t.Errorf("unexpected md3: %+v", md3)
+	}
+}
+
+func TestParseSmartctlATA(t *testing.T) {
+	data := []byte(`{
+		"model_name": "Samsung SSD 870 EVO 1TB",
+		"serial_number": "S6PTNX0R123456",
+		"smart_status": {"passed": true},
+		"temperature": {"current": 34},
+		"power_on_time": {"hours": 8123},
+		"ata_smart_attributes": {"table": [
+			{"id": 5, "name": "Reallocated_Sector_Ct", "value": 100, "raw": {"value": 2}},
+			{"id": 177, "name": "Wear_Leveling_Count", "value": 97, "raw": {"value": 31}},
+			{"id": 197, "name": "Current_Pending_Sector", "value": 100, "raw": {"value": 1}}
+		]}
+	}`)
+	dev, err := parseSmartctl(data)
+	if err != nil {
+		t.Fatalf("parseSmartctl failed: %v", err)
+	}
+	if dev.Health != "PASSED" || dev.Temperature != 34 || dev.PowerOnHours != 8123 {
+		t.Errorf("unexpected device: %+v", dev)
+	}
+	if dev.ReallocatedSectors != 2 || dev.PendingSectors != 1 {
+		t.Errorf("unexpected sector counts: %+v", dev)
+	}
+	if dev.WearUsed == nil || *dev.WearUsed != 3 {
+		t.Errorf("unexpected wear: %v", dev.WearUsed)
+	}
+}
+
+func TestParseSmartctlNVMe(t *testing.T) {
+	data := []byte(`{
+		"model_name": "WD_BLACK SN850X",
+		"smart_status": {"passed": false},
+		"temperature": {"current": 51},
+		"nvme_smart_health_information_log": {"percentage_used": 12, "media_errors": 4}
+	}`)
+	dev, err := parseSmartctl(data)
+	if err != nil {
+		t.Fatalf("parseSmartctl failed: %v", err)
+	}
+	if dev.Health != "FAILED" || dev.MediaErrors != 4 || dev.WearUsed == nil || *dev.WearUsed != 12 {
+		t.Errorf("unexpected device: %+v", dev)
+	}
+
+	dev, _ = parseSmartctl([]byte(`{"model_name": "USB bridge"}`))
+	if dev.Health != "UNKNOWN" || dev.WearUsed != nil {
+		t.Errorf("unexpected device without SMART: %+v", dev)
+	}
+}
+
+func TestDiffDiskHealth(t *testing.T) {
+	prev := DiskHealth{
+		Raid:  []RaidArray{{Name: "md0", State: "active"}, {Name: "md1", State: "active"}},
+		Smart: []SmartDevice{{Device: "/dev/sda", Health: "PASSED"}, {Device: "/dev/sdb", Health: "PASSED"}},
+	}
+	cur := DiskHealth{
+		Raid:  []RaidArray{{Name: "md0", State: "active"}, {Name: "md1", State: "degraded", Failed: []string{"sdc1"}}},
+		Smart: []SmartDevice{{Device: "/dev/sda", Health: "PASSED", ReallocatedSectors: 8}, {Device: "/dev/sdb", Health: "PASSED"}, {Device: "/dev/sdc", Health: "FAILED"}},
+	}
+	changed := diffDiskHealth(prev, cur)
+	if len(changed.Raid) != 1 || changed.Raid[0].Name != "md1" {
+		t.Errorf("unexpected raid changes: %+v", changed.Raid)
+	}
+	if len(changed.Smart) != 1 || changed.Smart[0].Device != "/dev/sda" {
+		t.Errorf("unexpected smart changes: %+v", changed.Smart)
+	}
+}
+
+func TestDiffDiskHealthRemovedArray(t *testing.T) {
+	prev := DiskHealth{Raid: []RaidArray{
+		{Name: "md0", Level: "raid1", State: "active", Devices: 2, ActiveDevices: 2},
+		{Name: "md1", State: "active"},
+	}}
+	cur := DiskHealth{Raid: []RaidArray{{Name: "md1", State: "active"}}}
+	changed := diffDiskHealth(prev, cur)
+	if len(changed.Raid) != 1 {
+		t.Fatalf("unexpected raid changes: %+v", changed.Raid)
+	}
+	if r := changed.Raid[0]; r.Name != "md0" || r.State != "removed" || r.Level != "raid1" || r.ActiveDevices != 0 {
+		t.Errorf("unexpected removed array: %+v", r)
+	}
+}
@@ -0,0 +1,63 @@
+package monitoring
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// hostRootEnv gopsutil 读取的宿主机目录环境变量及其相对于宿主机根目录的路径
+var hostRootEnv = []struct {
+	env string
+	dir string
+}{
+	{"HOST_ROOT", ""},
+	{"HOST_PROC", "proc"},
+	{"HOST_SYS", "sys"},
+	{"HOST_ETC", "etc"},
+	{"HOST_VAR", "var"},
+	{"HOST_RUN", "run"},
+	{"HOST_DEV", "dev"},
+}
+
+// HostRoot 返回宿主机根目录在 agent 视角下的路径，未设置 --host-root 时为 "/"
+func HostRoot() string {
+	if flags.HostRoot == "" {
+		return "/"
+	}
+	return flags.HostRoot
+}
+
+// hostPath 将宿主机上的绝对路径转换为 agent 视角下的路径
+func hostPath(elem ...string) string {
+	return filepath.Join(append([]string{HostRoot()}, elem...)...)
+}
+
+// isHostRootSet 是否通过 --host-root 监控挂载进来的宿主机
+func isHostRootSet() bool {
+	return HostRoot() != "/"
+}
+
+// SetupHostRoot 将 gopsutil 的 HOST_* 环境变量指向 --host-root 下的对应目录，已设置的环境变量保持不变
+func SetupHostRoot() {
+	if !isHostRootSet() {
+		return
+	}
+	if st, err := os.Stat(flags.HostRoot); err != nil || !st.IsDir() {
+		log.Printf("Host root %s is not a directory, using container view", flags.HostRoot)
+		flags.HostRoot = ""
+		return
+	}
+	for _, m := range hostRootEnv {
+		if os.Getenv(m.env) != "" {
+			continue
+		}
+		p := hostPath(m.dir)
+		if st, err := os.Stat(p); err == nil && st.IsDir() {
+			os.Setenv(m.env, p)
+		}
+	}
+	log.Printf("Monitoring host mounted at %s", flags.HostRoot)
+}
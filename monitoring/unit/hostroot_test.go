@@ -0,0 +1,61 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+func TestHostPath(t *testing.T) {
+	old := flags.HostRoot
+	defer func() { flags.HostRoot = old }()
+
+	flags.HostRoot = ""
+	if got := hostPath("proc", "1", "stat"); got != filepath.Join("/", "proc", "1", "stat") {
+		t.Errorf("hostPath without host root = %q", got)
+	}
+	if isHostRootSet() {
+		t.Error("host root should not be set")
+	}
+
+	flags.HostRoot = "/host"
+	if got := hostPath("etc/os-release"); got != filepath.Join("/host", "etc", "os-release") {
+		t.Errorf("hostPath with host root = %q", got)
+	}
+	if got := hostPath("/var/run/docker.sock"); got != filepath.Join("/host", "var", "run", "docker.sock") {
+		t.Errorf("hostPath with absolute element = %q", got)
+	}
+}
+
+func TestSetupHostRootFallsBackWhenMissing(t *testing.T) {
+	old := flags.HostRoot
+	defer func() { flags.HostRoot = old }()
+
+	flags.HostRoot = filepath.Join(t.TempDir(), "missing")
+	SetupHostRoot()
+	if flags.HostRoot != "" {
+		t.Errorf("expected host root to be cleared, got %q", flags.HostRoot)
+	}
+}
+
+func TestSetupHostRootExportsGopsutilEnv(t *testing.T) {
+	old := flags.HostRoot
+	defer func() { flags.HostRoot = old }()
+
+	root := t.TempDir()
+	os.Mkdir(filepath.Join(root, "proc"), 0o755)
+	t.Setenv("HOST_ROOT", "")
+	t.Setenv("HOST_PROC", "")
+	t.Setenv("HOST_ETC", "/custom/etc")
+
+	flags.HostRoot = root
+	SetupHostRoot()
+	if got := os.Getenv("HOST_PROC"); got != filepath.Join(root, "proc") {
+		t.Errorf("HOST_PROC = %q", got)
+	}
+	if got := os.Getenv("HOST_ETC"); got != "/custom/etc" {
+		t.Errorf("HOST_ETC should be kept, got %q", got)
+	}
+}
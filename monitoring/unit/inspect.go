@@ -0,0 +1,143 @@
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// DiskInspection 单个挂载点是否计入磁盘统计及其原因
+type DiskInspection struct {
+	Mountpoint string `json:"mountpoint"`
+	Device     string `json:"device"`
+	Fstype     string `json:"fstype"`
+	Included   bool   `json:"included"`
+	Reason     string `json:"reason"`
+}
+
+// NicInspection 单个网卡是否计入流量统计及其原因
+type NicInspection struct {
+	Name      string `json:"name"`
+	Source    string `json:"source"` // gopsutil / traffic state
+	Included  bool   `json:"included"`
+	Reason    string `json:"reason"`
+	BytesSent uint64 `json:"bytes_sent"`
+	BytesRecv uint64 `json:"bytes_recv"`
+}
+
+// InspectDisks 列出所有分区以及 Disk() 对每个分区的取舍，规则与 Disk() 一致
+func InspectDisks() ([]DiskInspection, error) {
+	parts, err := disk.Partitions(true)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]DiskInspection, 0, len(parts))
+
+	if flags.IncludeMountpoints != "" {
+		listed := map[string]bool{}
+		for _, mp := range strings.Split(flags.IncludeMountpoints, ";") {
+			if mp = strings.TrimSpace(mp); mp != "" {
+				listed[mp] = false
+			}
+		}
+		for _, part := range parts {
+			d := DiskInspection{Mountpoint: part.Mountpoint, Device: part.Device, Fstype: part.Fstype}
+			if _, ok := listed[part.Mountpoint]; ok {
+				listed[part.Mountpoint] = true
+				d.Included, d.Reason = true, "listed in --include-mountpoint"
+			} else {
+				d.Reason = "not listed in --include-mountpoint"
+			}
+			result = append(result, d)
+		}
+		var missing []string
+		for mp, found := range listed {
+			if !found {
+				missing = append(missing, mp)
+			}
+		}
+		sort.Strings(missing)
+		for _, mp := range missing {
+			d := DiskInspection{Mountpoint: mp, Reason: "listed in --include-mountpoint but not in the partition table"}
+			if _, err := diskUsage(mp); err == nil {
+				d.Included = true
+			} else {
+				d.Reason += ": " + err.Error()
+			}
+			result = append(result, d)
+		}
+		return result, nil
+	}
+
+	poolOf := map[string]string{}
+	for _, pool := range groupStoragePools(parts) {
+		for _, mp := range pool.Mountpoints {
+			poolOf[mp] = fmt.Sprintf("%s pool %s", pool.Type, pool.Name)
+		}
+	}
+	for _, part := range parts {
+		d := DiskInspection{Mountpoint: part.Mountpoint, Device: part.Device, Fstype: part.Fstype}
+		switch reason := diskExcludeReason(part); {
+		case reason != "":
+			d.Reason = reason
+		case isPoolFilesystem(part):
+			d.Included, d.Reason = true, "counted once as "+poolOf[part.Mountpoint]
+		case part.Mountpoint == "/":
+			d.Included, d.Reason = true, "root mountpoint is always included"
+		default:
+			d.Included, d.Reason = true, "physical disk"
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// InspectNics 列出所有网卡以及流量统计对每个网卡的取舍，规则与 NetworkSpeed() 一致
+func InspectNics() ([]NicInspection, error) {
+	includeNics := parseNics(flags.IncludeNics)
+	excludeNics := parseNics(flags.ExcludeNics)
+	result := []NicInspection{}
+
+	// 启用月重置时展示当前计费周期的累计流量，包括本周期内已消失的网卡；
+	// 诊断命令已设置只读，这里不会写回状态文件
+	period := map[string][2]uint64{}
+	if flags.MonthRotate != 0 {
+		if _, _, err := PeriodTraffic(nil, nil); err != nil {
+			return nil, err
+		}
+		period = periodTrafficByInterface()
+		for name, v := range period {
+			result = append(result, newNicInspection(name, "traffic state", v[0], v[1], includeNics, excludeNics))
+		}
+	}
+	counters, err := net.IOCounters(true)
+	if err != nil {
+		return nil, err
+	}
+	// 未被统计的网卡不在状态文件中，仍按原始计数展示排除原因
+	for _, c := range counters {
+		if _, ok := period[c.Name]; !ok {
+			result = append(result, newNicInspection(c.Name, "gopsutil", c.BytesSent, c.BytesRecv, includeNics, excludeNics))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func newNicInspection(name, source string, sent, recv uint64, includeNics, excludeNics map[string]struct{}) NicInspection {
+	n := NicInspection{Name: name, Source: source, BytesSent: sent, BytesRecv: recv}
+	n.Reason = nicExcludeReason(name, includeNics, excludeNics)
+	if n.Reason == "" {
+		n.Included = true
+		if len(includeNics) > 0 {
+			n.Reason = "listed in --include-nics"
+		} else {
+			n.Reason = "not excluded"
+		}
+	}
+	return n
+}
@@ -2,13 +2,17 @@ package monitoring
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
 	"regexp"
+	"strings"
 	"time"
 
+	"github.com/komari-monitor/komari-agent/cmd/flags"
 	"github.com/komari-monitor/komari-agent/dnsresolver"
 )
 
@@ -43,9 +47,8 @@ var (
 	userAgent = "curl/8.0.1"
 )
 
-func GetIPv4Address() (string, error) {
-
-	webAPIs := []string{
+var (
+	ipv4APIs = []string{
 		"https://www.visa.cn/cdn-cgi/trace",
 		"https://www.qualcomm.cn/cdn-cgi/trace",
 		"https://www.toutiao.com/stream/widget/local_weather/data/",
@@ -54,71 +57,230 @@ func GetIPv4Address() (string, error) {
 		"http://ipv4.ip.sb",
 		"https://api.ipify.org?format=json",
 	}
+	ipv6APIs = []string{
+		"https://v6.ip.zxinc.org/info.php?type=json",
+		"https://api6.ipify.org?format=json",
+		"https://ipv6.icanhazip.com",
+		"https://api-ipv6.ip.sb/geoip",
+	}
+	ipv4Pattern = regexp.MustCompile(`\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}`)
+	// 使用正则表达式从响应体中提取IPv6地址
+	ipv6Pattern = regexp.MustCompile(`(([0-9A-Fa-f]{1,4}:){7})([0-9A-Fa-f]{1,4})|(([0-9A-Fa-f]{1,4}:){1,6}:)(([0-9A-Fa-f]{1,4}:){0,4})([0-9A-Fa-f]{0,4})`)
+)
 
-	for _, api := range webAPIs {
-		// get ipv4
-		req, err := http.NewRequest("GET", api, nil)
-		if err != nil {
-			continue
-		}
-		req.Header.Set("User-Agent", userAgent)
-		resp, err := ipv4HTTPClient.Do(req)
-		if err != nil {
-			continue
+// IPProbe 单个公网 IP 查询接口的结果
+type IPProbe struct {
+	Family string `json:"family"`
+	API    string `json:"api"`
+	IP     string `json:"ip,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ipFamily 一个地址族的查询方式
+type ipFamily struct {
+	name    string
+	client  *http.Client
+	apis    []string // 内置接口列表
+	pattern *regexp.Regexp
+	network string // STUN 使用的网络类型
+}
+
+var (
+	ipv4Family = ipFamily{"ipv4", ipv4HTTPClient, ipv4APIs, ipv4Pattern, "udp4"}
+	ipv6Family = ipFamily{"ipv6", ipv6HTTPClient, ipv6APIs, ipv6Pattern, "udp6"}
+)
+
+// ipDiscoveryMethods 解析 --ip-discovery，按顺序尝试；none 表示不获取公网 IP
+func ipDiscoveryMethods() []string {
+	var methods []string
+	for _, m := range strings.Split(flags.IPDiscovery, ",") {
+		switch m = strings.ToLower(strings.TrimSpace(m)); m {
+		case "":
+		case "none", "off", "disabled":
+			return nil
+		case "web", "local", "stun":
+			methods = append(methods, m)
+		default:
+			log.Printf("Unknown IP discovery method %q, ignored", m)
 		}
-		body, err := io.ReadAll(resp.Body)
-		_ = resp.Body.Close() // 获取后立即关闭防止堵塞
-		if err != nil {
+	}
+	if len(methods) == 0 && strings.TrimSpace(flags.IPDiscovery) == "" {
+		return []string{"web"}
+	}
+	return methods
+}
+
+// endpoints 返回 --ip-endpoints 配置的接口，未配置时使用内置列表；以 "/" 开头的地址视为 Komari 服务端上的路径
+func (f ipFamily) endpoints() []string {
+	if strings.TrimSpace(flags.IPEndpoints) == "" {
+		return f.apis
+	}
+	var apis []string
+	for _, api := range strings.Split(flags.IPEndpoints, ",") {
+		api = strings.TrimSpace(api)
+		if api == "" {
 			continue
 		}
-		re := regexp.MustCompile(`\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}`)
-		ipv4 := re.FindString(string(body))
-		if ipv4 != "" {
-			log.Printf("Get IPV4 Success: %s", ipv4)
-			return ipv4, nil
+		if strings.HasPrefix(api, "/") {
+			if flags.Endpoint == "" {
+				continue
+			}
+			api = strings.TrimSuffix(flags.Endpoint, "/") + api
 		}
+		apis = append(apis, api)
 	}
-	return "", nil
+	return apis
 }
 
-func GetIPv6Address() (string, error) {
+// queryIP 请求单个接口并从响应中提取 IP
+func queryIP(client *http.Client, api string, pattern *regexp.Regexp) (string, error) {
+	req, err := http.NewRequest("GET", api, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	// 请求 Komari 服务端时附带 token 与 Cloudflare Access 头部
+	if flags.Endpoint != "" && strings.HasPrefix(api, strings.TrimSuffix(flags.Endpoint, "/")+"/") {
+		q := req.URL.Query()
+		q.Set("token", flags.Token)
+		req.URL.RawQuery = q.Encode()
+		if flags.CFAccessClientID != "" && flags.CFAccessClientSecret != "" {
+			req.Header.Set("CF-Access-Client-Id", flags.CFAccessClientID)
+			req.Header.Set("CF-Access-Client-Secret", flags.CFAccessClientSecret)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close() // 获取后立即关闭防止堵塞
+	if err != nil {
+		return "", err
+	}
+	ip := pattern.FindString(string(body))
+	if ip == "" {
+		return "", fmt.Errorf("no address found in response (%s)", resp.Status)
+	}
+	return ip, nil
+}
 
-	webAPIs := []string{
-		"https://v6.ip.zxinc.org/info.php?type=json",
-		"https://api6.ipify.org?format=json",
-		"https://ipv6.icanhazip.com",
-		"https://api-ipv6.ip.sb/geoip",
+// localIP 从被统计网卡的地址中选取公网地址；allowPrivate 时没有公网地址可退回内网地址
+func localIP(family string, allowPrivate bool) (string, error) {
+	ifaces, err := NetInterfaces()
+	if err != nil {
+		return "", err
 	}
+	return pickLocalIP(ifaces, family == "ipv6", allowPrivate)
+}
 
-	for _, api := range webAPIs {
-		// get ipv6
-		req, err := http.NewRequest("GET", api, nil)
-		if err != nil {
+func pickLocalIP(ifaces []NetInterface, v6, allowPrivate bool) (string, error) {
+	private := ""
+	for _, iface := range ifaces {
+		if !iface.Up {
 			continue
 		}
-		req.Header.Set("User-Agent", userAgent)
-		resp, err := ipv6HTTPClient.Do(req)
-		if err != nil {
-			continue
+		for _, addr := range iface.Addresses {
+			ip, _, err := net.ParseCIDR(addr)
+			if err != nil || !ip.IsGlobalUnicast() || (ip.To4() == nil) != v6 {
+				continue
+			}
+			if !ip.IsPrivate() {
+				return ip.String(), nil
+			}
+			if private == "" {
+				private = ip.String()
+			}
 		}
-		body, err := io.ReadAll(resp.Body)
-		_ = resp.Body.Close() // 获取后立即关闭防止堵塞
-		if err != nil {
-			continue
+	}
+	if private == "" {
+		return "", errors.New("no usable address on monitored interfaces")
+	}
+	if !allowPrivate {
+		return "", fmt.Errorf("no public address on monitored interfaces (found private %s)", private)
+	}
+	return private, nil
+}
+
+// sources 返回一种方式下依次尝试的查询来源
+func (f ipFamily) sources(method string) []string {
+	switch method {
+	case "web":
+		return f.endpoints()
+	case "local":
+		return []string{"local"}
+	case "stun":
+		return []string{"stun:" + flags.StunServer}
+	}
+	return nil
+}
+
+// query 使用一种方式查询地址；last 表示这是最后一种方式，此时 local 才接受内网地址，
+// 否则 NAT 后的机器会把内网地址当作公网 IP 上报，而不再尝试后面的方式
+func (f ipFamily) query(method, source string, last bool) (string, error) {
+	switch method {
+	case "local":
+		return localIP(f.name, last)
+	case "stun":
+		return stunIP(f.network, strings.TrimPrefix(source, "stun:"))
+	default:
+		return queryIP(f.client, source, f.pattern)
+	}
+}
+
+// discover 按 --ip-discovery 的顺序尝试各方式，返回第一个获取到的地址
+func (f ipFamily) discover() string {
+	methods := ipDiscoveryMethods()
+	for i, method := range methods {
+		for _, src := range f.sources(method) {
+			if ip, err := f.query(method, src, i == len(methods)-1); err == nil {
+				return ip
+			}
 		}
+	}
+	return ""
+}
+
+func GetIPv4Address() (string, error) {
+	ipv4 := ipv4Family.discover()
+	if ipv4 != "" {
+		log.Printf("Get IPV4 Success: %s", ipv4)
+	}
+	return ipv4, nil
+}
+
+func GetIPv6Address() (string, error) {
+	ipv6 := ipv6Family.discover()
+	if ipv6 != "" {
+		log.Printf("Get IPV6 Success:  %s", ipv6)
+	}
+	return ipv6, nil
+}
 
-		// 使用正则表达式从响应体中提取IPv6地址
-		re := regexp.MustCompile(`(([0-9A-Fa-f]{1,4}:){7})([0-9A-Fa-f]{1,4})|(([0-9A-Fa-f]{1,4}:){1,6}:)(([0-9A-Fa-f]{1,4}:){0,4})([0-9A-Fa-f]{0,4})`)
-		ipv6 := re.FindString(string(body))
-		if ipv6 != "" {
-			log.Printf("Get IPV6 Success:  %s", ipv6)
-			return ipv6, nil
+// ProbeIPAddress 按 --ip-discovery 依次尝试所有方式与接口并返回每一项的结果，用于诊断
+func ProbeIPAddress() []IPProbe {
+	var probes []IPProbe
+	for _, f := range []ipFamily{ipv4Family, ipv6Family} {
+		methods := ipDiscoveryMethods()
+		for i, method := range methods {
+			for _, src := range f.sources(method) {
+				p := IPProbe{Family: f.name, API: src}
+				ip, err := f.query(method, src, i == len(methods)-1)
+				if err != nil {
+					p.Error = err.Error()
+				}
+				p.IP = ip
+				probes = append(probes, p)
+			}
 		}
 	}
-	return "", nil
+	return probes
 }
 
 func GetIPAddress() (ipv4, ipv6 string, err error) {
+	if len(ipDiscoveryMethods()) == 0 {
+		return "", "", nil
+	}
 	ipv4, err = GetIPv4Address()
 	if err != nil {
 		log.Printf("Get IPV4 Error: %v", err)
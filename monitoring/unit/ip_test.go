@@ -0,0 +1,124 @@
+package monitoring
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+func TestIPDiscoveryMethods(t *testing.T) {
+	old := flags.IPDiscovery
+	defer func() { flags.IPDiscovery = old }()
+
+	tests := map[string][]string{
+		"":            {"web"},
+		"web":         {"web"},
+		"stun, local": {"stun", "local"},
+		"none":        nil,
+		"local,off":   nil,
+		"bogus":       nil,
+	}
+	for spec, expected := range tests {
+		flags.IPDiscovery = spec
+		if got := ipDiscoveryMethods(); !reflect.DeepEqual(got, expected) {
+			t.Errorf("ipDiscoveryMethods(%q) = %v, want %v", spec, got, expected)
+		}
+	}
+}
+
+func TestIPEndpoints(t *testing.T) {
+	oldEndpoints, oldEndpoint := flags.IPEndpoints, flags.Endpoint
+	defer func() { flags.IPEndpoints, flags.Endpoint = oldEndpoints, oldEndpoint }()
+
+	flags.IPEndpoints = ""
+	if got := ipv4Family.endpoints(); !reflect.DeepEqual(got, ipv4APIs) {
+		t.Errorf("default endpoints = %v", got)
+	}
+
+	flags.IPEndpoints = "https://ip.example.com, /api/ip"
+	flags.Endpoint = "https://komari.example.com/"
+	expected := []string{"https://ip.example.com", "https://komari.example.com/api/ip"}
+	if got := ipv6Family.endpoints(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("configured endpoints = %v, want %v", got, expected)
+	}
+
+	// 未配置服务端地址时忽略相对路径
+	flags.Endpoint = ""
+	if got := ipv4Family.endpoints(); !reflect.DeepEqual(got, expected[:1]) {
+		t.Errorf("endpoints without server = %v", got)
+	}
+}
+
+func TestPickLocalIP(t *testing.T) {
+	ifaces := []NetInterface{
+		{Name: "eth0", Up: true, Addresses: []string{"192.168.1.10/24", "fe80::1/64", "fd00::10/64"}},
+		{Name: "eth1", Up: false, Addresses: []string{"203.0.113.9/24"}},
+		{Name: "eth2", Up: true, Addresses: []string{"198.51.100.7/24", "2001:db8::7/64"}},
+	}
+	if ip, err := pickLocalIP(ifaces, false, false); err != nil || ip != "198.51.100.7" {
+		t.Errorf("ipv4 = %q, %v", ip, err)
+	}
+	if ip, err := pickLocalIP(ifaces, true, false); err != nil || ip != "2001:db8::7" {
+		t.Errorf("ipv6 = %q, %v", ip, err)
+	}
+	// 只有内网地址时，仅在 local 为最后一种方式时退回内网地址
+	if ip, err := pickLocalIP(ifaces[:1], false, false); err == nil {
+		t.Errorf("private ipv4 %q should not be reported as public", ip)
+	}
+	if ip, err := pickLocalIP(ifaces[:1], false, true); err != nil || ip != "192.168.1.10" {
+		t.Errorf("private ipv4 = %q, %v", ip, err)
+	}
+	if _, err := pickLocalIP(ifaces[1:2], false, true); err == nil {
+		t.Error("down interface should not be used")
+	}
+}
+
+func TestParseStunResponse(t *testing.T) {
+	txID := []byte("0123456789ab")
+	build := func(attrType uint16, value []byte) []byte {
+		msg := make([]byte, stunHeaderLength+4+len(value))
+		binary.BigEndian.PutUint16(msg[0:2], stunBindingSuccess)
+		binary.BigEndian.PutUint16(msg[2:4], uint16(4+len(value)))
+		binary.BigEndian.PutUint32(msg[4:8], stunMagicCookie)
+		copy(msg[8:20], txID)
+		binary.BigEndian.PutUint16(msg[20:22], attrType)
+		binary.BigEndian.PutUint16(msg[22:24], uint16(len(value)))
+		copy(msg[24:], value)
+		return msg
+	}
+
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint32(key[0:4], stunMagicCookie)
+	copy(key[4:], txID)
+	xor := func(ip net.IP) []byte {
+		out := make([]byte, len(ip))
+		for i := range ip {
+			out[i] = ip[i] ^ key[i]
+		}
+		return out
+	}
+
+	v4 := net.ParseIP("203.0.113.5").To4()
+	value := append([]byte{0, stunFamilyIPv4, 0, 0}, xor(v4)...)
+	if ip, err := parseStunResponse(build(stunXorMappedAddr, value), txID); err != nil || !ip.Equal(v4) {
+		t.Errorf("xor ipv4 = %v, %v", ip, err)
+	}
+
+	v6 := net.ParseIP("2001:db8::5")
+	value = append([]byte{0, stunFamilyIPv6, 0, 0}, xor(v6)...)
+	if ip, err := parseStunResponse(build(stunXorMappedAddr, value), txID); err != nil || !ip.Equal(v6) {
+		t.Errorf("xor ipv6 = %v, %v", ip, err)
+	}
+
+	value = append([]byte{0, stunFamilyIPv4, 0, 0}, v4...)
+	if ip, err := parseStunResponse(build(stunMappedAddress, value), txID); err != nil || !ip.Equal(v4) {
+		t.Errorf("mapped ipv4 = %v, %v", ip, err)
+	}
+
+	if _, err := parseStunResponse(build(stunMappedAddress, value), []byte("other-txid!!")); err == nil {
+		t.Error("mismatched transaction id should fail")
+	}
+}
@@ -0,0 +1,130 @@
+package monitoring
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubernetesNode DaemonSet 模式下所在节点的元数据
+type KubernetesNode struct {
+	Name   string            `json:"name"`
+	Zone   string            `json:"zone,omitempty"`
+	Region string            `json:"region,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// SetupKubernetesMode 未指定 --host-root 时以 --kubernetes-host-mount 作为宿主机根目录
+func SetupKubernetesMode() {
+	if !flags.Kubernetes {
+		return
+	}
+	if flags.HostRoot == "" {
+		flags.HostRoot = flags.KubernetesHostMount
+	}
+	log.Printf("Kubernetes mode: node %s", KubernetesNodeName())
+}
+
+// KubernetesNodeName 从 Downward API 注入的环境变量读取节点名
+func KubernetesNodeName() string {
+	for _, env := range []string{"NODE_NAME", "KUBERNETES_NODE_NAME"} {
+		if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Hostname 返回 agent 的身份名称：Kubernetes 模式下为节点名，否则为主机名
+func Hostname() string {
+	if flags.Kubernetes {
+		if node := KubernetesNodeName(); node != "" {
+			return node
+		}
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
+
+// KubernetesNodeInfo 使用 Pod 的 ServiceAccount 从 API Server 读取节点标签与可用区
+func KubernetesNodeInfo() (*KubernetesNode, error) {
+	node := KubernetesNodeName()
+	if node == "" {
+		return nil, errors.New("node name not set, expose spec.nodeName as NODE_NAME via the downward API")
+	}
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return &KubernetesNode{Name: node}, errors.New("not running in a Kubernetes cluster")
+	}
+	token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return &KubernetesNode{Name: node}, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return &KubernetesNode{Name: node}, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca)
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}
+	baseURL := "https://" + net.JoinHostPort(host, port)
+	return fetchKubernetesNode(client, baseURL, strings.TrimSpace(string(token)), node)
+}
+
+func fetchKubernetesNode(client *http.Client, baseURL, token, node string) (*KubernetesNode, error) {
+	info := &KubernetesNode{Name: node}
+	req, err := http.NewRequest("GET", baseURL+"/api/v1/nodes/"+node, nil)
+	if err != nil {
+		return info, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return info, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return info, fmt.Errorf("failed to get node %s: %s", node, resp.Status)
+	}
+
+	var body struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return info, err
+	}
+	info.Labels = body.Metadata.Labels
+	info.Zone = firstLabel(info.Labels, "topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone")
+	info.Region = firstLabel(info.Labels, "topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region")
+	return info, nil
+}
+
+func firstLabel(labels map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := labels[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
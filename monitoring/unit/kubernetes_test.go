@@ -0,0 +1,36 @@
+package monitoring
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchKubernetesNode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/nodes/worker-1" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"metadata":{"labels":{
+			"kubernetes.io/hostname":"worker-1",
+			"topology.kubernetes.io/zone":"eu-west-1a",
+			"failure-domain.beta.kubernetes.io/region":"eu-west-1"}}}`))
+	}))
+	defer srv.Close()
+
+	node, err := fetchKubernetesNode(srv.Client(), srv.URL, "secret", "worker-1")
+	if err != nil {
+		t.Fatalf("fetchKubernetesNode failed: %v", err)
+	}
+	if node.Name != "worker-1" || node.Zone != "eu-west-1a" || node.Region != "eu-west-1" {
+		t.Errorf("unexpected node: %+v", node)
+	}
+	if node.Labels["kubernetes.io/hostname"] != "worker-1" {
+		t.Errorf("labels not parsed: %v", node.Labels)
+	}
+
+	if _, err := fetchKubernetesNode(srv.Client(), srv.URL, "wrong", "worker-1"); err == nil {
+		t.Error("expected error for unauthorized request")
+	}
+}
@@ -0,0 +1,193 @@
+package monitoring
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// Listener 一个监听中的 TCP 端口或未连接的 UDP 端口及其所属进程
+type Listener struct {
+	Proto   string `json:"proto"` // tcp / tcp6 / udp / udp6
+	Address string `json:"address"`
+	Port    uint32 `json:"port"`
+	PID     int32  `json:"pid,omitempty"`
+	Process string `json:"process,omitempty"`
+	User    string `json:"user,omitempty"`
+	Change  string `json:"change,omitempty"` // 事件中的变化类型：added / removed
+}
+
+func (l Listener) key() string {
+	return fmt.Sprintf("%s|%s|%d", l.Proto, l.Address, l.Port)
+}
+
+// 枚举套接字所属进程需要遍历所有进程的文件描述符，检查间隔长于其他监视项
+const listenerPollInterval = 30 * time.Second
+
+var (
+	listenerMu     sync.Mutex
+	listenerOnce   sync.Once
+	listenerState  []Listener
+	listenerEvents = make(chan []Listener, 16)
+)
+
+// Listeners 列出所有监听端口，同一地址端口被多个进程（SO_REUSEPORT）或多个套接字共享时只保留一条
+func Listeners() ([]Listener, error) {
+	conns, err := net.Connections("inet")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sockets: %w", err)
+	}
+	seen := map[string]int{}
+	names := map[int32]string{}
+	result := []Listener{}
+	for _, c := range conns {
+		l, ok := listenerFromConn(c)
+		if !ok {
+			continue
+		}
+		if i, dup := seen[l.key()]; dup {
+			if result[i].PID == 0 || (l.PID != 0 && l.PID < result[i].PID) {
+				result[i].PID = l.PID
+			}
+			continue
+		}
+		seen[l.key()] = len(result)
+		result = append(result, l)
+	}
+	for i := range result {
+		pid := result[i].PID
+		if pid == 0 {
+			continue
+		}
+		if _, ok := names[pid]; !ok {
+			if p, err := process.NewProcess(pid); err == nil {
+				names[pid], _ = p.Name()
+			}
+		}
+		result[i].Process = names[pid]
+		result[i].User = processUser(pid)
+	}
+	sortListeners(result)
+	return result, nil
+}
+
+// listenerFromConn TCP 取 LISTEN 状态，UDP 取没有远端地址的套接字
+func listenerFromConn(c net.ConnectionStat) (Listener, bool) {
+	var proto string
+	switch c.Type {
+	case 1: // SOCK_STREAM
+		if c.Status != "LISTEN" {
+			return Listener{}, false
+		}
+		proto = "tcp"
+	case 2: // SOCK_DGRAM
+		if c.Raddr.Port != 0 {
+			return Listener{}, false
+		}
+		proto = "udp"
+	default:
+		return Listener{}, false
+	}
+	if c.Family != 2 { // AF_INET
+		proto += "6"
+	}
+	return Listener{Proto: proto, Address: c.Laddr.IP, Port: c.Laddr.Port, PID: c.Pid}, true
+}
+
+func sortListeners(listeners []Listener) {
+	sort.Slice(listeners, func(i, j int) bool {
+		a, b := listeners[i], listeners[j]
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		if a.Proto != b.Proto {
+			return a.Proto < b.Proto
+		}
+		return a.Address < b.Address
+	})
+}
+
+// StartListenerWatch 在 --listeners 开启时后台检查监听端口，出现或消失时推送事件
+func StartListenerWatch() {
+	if !flags.EnableListeners {
+		return
+	}
+	listenerOnce.Do(func() {
+		cur, err := Listeners()
+		if err != nil {
+			log.Println("Failed to list listening ports:", err)
+		}
+		listenerMu.Lock()
+		listenerState = cur
+		listenerMu.Unlock()
+		go func() {
+			ticker := time.NewTicker(listenerPollInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				pollListeners()
+			}
+		}()
+	})
+}
+
+// ListenerStatus 返回最近一次检查的监听端口
+func ListenerStatus() []Listener {
+	listenerMu.Lock()
+	defer listenerMu.Unlock()
+	return listenerState
+}
+
+// ListenerEvents 新增或消失的监听端口
+func ListenerEvents() <-chan []Listener {
+	return listenerEvents
+}
+
+func pollListeners() {
+	cur, err := Listeners()
+	if err != nil {
+		log.Println("Failed to list listening ports:", err)
+		return
+	}
+	listenerMu.Lock()
+	prev := listenerState
+	listenerState = cur
+	listenerMu.Unlock()
+
+	if changed := diffListeners(prev, cur); len(changed) > 0 {
+		select {
+		case listenerEvents <- changed:
+		default:
+			// 通道已满时丢弃，基本信息中仍会携带最新状态
+		}
+	}
+}
+
+// diffListeners 按协议、地址、端口比较，所属进程变化（如服务重启）不视为变化
+func diffListeners(prev, cur []Listener) []Listener {
+	var changed []Listener
+	old := map[string]bool{}
+	for _, l := range prev {
+		old[l.key()] = true
+	}
+	now := map[string]bool{}
+	for _, l := range cur {
+		now[l.key()] = true
+		if !old[l.key()] {
+			l.Change = "added"
+			changed = append(changed, l)
+		}
+	}
+	for _, l := range prev {
+		if !now[l.key()] {
+			l.Change = "removed"
+			changed = append(changed, l)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,68 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+func TestListenerFromConn(t *testing.T) {
+	tests := []struct {
+		conn     net.ConnectionStat
+		ok       bool
+		expected Listener
+	}{
+		{
+			conn:     net.ConnectionStat{Family: 2, Type: 1, Status: "LISTEN", Laddr: net.Addr{IP: "0.0.0.0", Port: 22}, Pid: 100},
+			ok:       true,
+			expected: Listener{Proto: "tcp", Address: "0.0.0.0", Port: 22, PID: 100},
+		},
+		{
+			conn:     net.ConnectionStat{Family: 10, Type: 1, Status: "LISTEN", Laddr: net.Addr{IP: "::", Port: 443}},
+			ok:       true,
+			expected: Listener{Proto: "tcp6", Address: "::", Port: 443},
+		},
+		{
+			conn: net.ConnectionStat{Family: 2, Type: 1, Status: "ESTABLISHED", Laddr: net.Addr{IP: "10.0.0.2", Port: 22}, Raddr: net.Addr{IP: "10.0.0.1", Port: 50000}},
+		},
+		{
+			conn:     net.ConnectionStat{Family: 2, Type: 2, Laddr: net.Addr{IP: "127.0.0.53", Port: 53}, Pid: 200},
+			ok:       true,
+			expected: Listener{Proto: "udp", Address: "127.0.0.53", Port: 53, PID: 200},
+		},
+		{
+			// 已连接的 UDP 套接字不是监听端口
+			conn: net.ConnectionStat{Family: 2, Type: 2, Laddr: net.Addr{IP: "10.0.0.2", Port: 41000}, Raddr: net.Addr{IP: "1.1.1.1", Port: 53}},
+		},
+	}
+	for i, tt := range tests {
+		l, ok := listenerFromConn(tt.conn)
+		if ok != tt.ok || (ok && l != tt.expected) {
+			t.Errorf("case %d: got %+v, %v, want %+v, %v", i, l, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestDiffListeners(t *testing.T) {
+	prev := []Listener{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22, PID: 100},
+		{Proto: "tcp", Address: "127.0.0.1", Port: 6379, PID: 300},
+	}
+	cur := []Listener{
+		{Proto: "tcp", Address: "0.0.0.0", Port: 22, PID: 101}, // 服务重启，PID 变化
+		{Proto: "tcp", Address: "0.0.0.0", Port: 8080, PID: 400},
+	}
+	changed := diffListeners(prev, cur)
+	if len(changed) != 2 {
+		t.Fatalf("changed = %+v", changed)
+	}
+	if changed[0].Port != 8080 || changed[0].Change != "added" {
+		t.Errorf("added = %+v", changed[0])
+	}
+	if changed[1].Port != 6379 || changed[1].Change != "removed" {
+		t.Errorf("removed = %+v", changed[1])
+	}
+	if changed := diffListeners(cur, cur); len(changed) != 0 {
+		t.Errorf("identical lists should not change: %+v", changed)
+	}
+}
@@ -20,6 +20,17 @@ func Ram() RamInfo {
 		raminfo.Used = 0
 		return raminfo
 	}
+	// 运行在设置了内存限制的容器/slice 中时，以 cgroup 限制为总量
+	if flags.CgroupAware {
+		if cg, ok := CgroupStats(); ok && cg.MemoryLimit > 0 && cg.MemoryLimit < v.Total {
+			raminfo.Total = cg.MemoryLimit
+			raminfo.Used = cg.MemoryWorkingSet
+			if flags.MemoryIncludeCache {
+				raminfo.Used = cg.MemoryUsage
+			}
+			return raminfo
+		}
+	}
 	if flags.MemoryIncludeCache {
 		raminfo.Total = v.Total
 		raminfo.Used = v.Total - v.Free
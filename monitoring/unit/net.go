@@ -11,19 +11,6 @@ import (
 	"github.com/shirou/gopsutil/v4/net"
 )
 
-func ConnectionsCount() (tcpCount, udpCount int, err error) {
-	tcps, err := net.Connections("tcp")
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get TCP connections: %w", err)
-	}
-	udps, err := net.Connections("udp")
-	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get UDP connections: %w", err)
-	}
-
-	return len(tcps), len(udps), nil
-}
-
 var (
 	// 预定义常见的回环和虚拟接口名称
 	loopbackNames = map[string]struct{}{
@@ -148,31 +135,7 @@ func getVnstatData() (map[string]VnstatInterface, error) {
 
 // calculateMonthlyUsage 计算从月重置日到当前日期的流量使用量
 func calculateMonthlyUsage(iface VnstatInterface, monthRotateDay int) (rx, tx uint64) {
-	now := time.Now()
-	currentYear := now.Year()
-	currentMonth := int(now.Month())
-	currentDay := now.Day()
-
-	// 确定统计的起始日期
-	var startYear, startMonth, startDay int
-	if currentDay >= monthRotateDay {
-		// 当前月的重置日已过，从当前月的重置日开始计算
-		startYear = currentYear
-		startMonth = currentMonth
-		startDay = monthRotateDay
-	} else {
-		// 当前月的重置日未到，从上个月的重置日开始计算
-		if currentMonth == 1 {
-			startYear = currentYear - 1
-			startMonth = 12
-		} else {
-			startYear = currentYear
-			startMonth = currentMonth - 1
-		}
-		startDay = monthRotateDay
-	}
-
-	startTime := time.Date(startYear, time.Month(startMonth), startDay, 0, 0, 0, 0, time.Local)
+	startTime := billingPeriodStart(time.Now(), monthRotateDay)
 
 	// 统计从起始时间到现在的流量
 	for _, entry := range iface.Traffic.Day {
@@ -204,32 +167,17 @@ func NetworkSpeed() (totalUp, totalDown, upSpeed, downSpeed uint64, err error) {
 	includeNics := parseNics(flags.IncludeNics)
 	excludeNics := parseNics(flags.ExcludeNics)
 
-	// 如果设置了月重置（非0），使用vnstat统计totalUp、totalDown
+	// 如果设置了月重置（非0），totalUp、totalDown 使用 agent 自身持久化的计费周期流量
 	if flags.MonthRotate != 0 {
-
-		vnstatData, err := getVnstatData()
+		// 对于实时速度，仍然使用gopsutil方法
+		_, _, upSpeed, downSpeed, err = getNetworkSpeedFallback(includeNics, excludeNics)
 		if err != nil {
-			// 如果vnstat失败，回退到原来的方法，并返回额外的错误信息
-			fallbackUp, fallbackDown, fallbackUpSpeed, fallbackDownSpeed, fallbackErr := getNetworkSpeedFallback(includeNics, excludeNics)
-			if fallbackErr != nil {
-				return fallbackUp, fallbackDown, fallbackUpSpeed, fallbackDownSpeed, fmt.Errorf("failed to call vnstat: %v; fallback error: %w", err, fallbackErr)
-			}
-			return fallbackUp, fallbackDown, fallbackUpSpeed, fallbackDownSpeed, fmt.Errorf("failed to call vnstat: %w", err)
-		}
-
-		// 使用vnstat数据计算当月（到重置日）的流量使用量
-		for interfaceName, interfaceData := range vnstatData {
-			if shouldInclude(interfaceName, includeNics, excludeNics) {
-				monthlyRx, monthlyTx := calculateMonthlyUsage(interfaceData, flags.MonthRotate)
-				totalUp += monthlyTx
-				totalDown += monthlyRx
-			}
+			return 0, 0, 0, 0, err
 		}
 
-		// 对于实时速度，仍然使用gopsutil方法
-		_, _, upSpeed, downSpeed, err = getNetworkSpeedFallback(includeNics, excludeNics)
+		totalUp, totalDown, err = PeriodTraffic(includeNics, excludeNics)
 		if err != nil {
-			return totalUp, totalDown, 0, 0, err
+			return 0, 0, upSpeed, downSpeed, err
 		}
 
 		return totalUp, totalDown, upSpeed, downSpeed, nil
@@ -300,44 +248,40 @@ func parseNics(nics string) map[string]struct{} {
 }
 
 func shouldInclude(nicName string, includeNics, excludeNics map[string]struct{}) bool {
+	return nicExcludeReason(nicName, includeNics, excludeNics) == ""
+}
+
+// nicExcludeReason 返回网卡被排除的原因，为空表示统计该网卡
+func nicExcludeReason(nicName string, includeNics, excludeNics map[string]struct{}) string {
 	// 默认排除回环接口
 	for loopbackName := range loopbackNames {
 		if strings.HasPrefix(nicName, loopbackName) {
-			return false
+			return fmt.Sprintf("loopback/virtual name prefix %q", loopbackName)
 		}
 	}
 
 	// 如果定义了白名单，则只包括白名单中的接口
 	if len(includeNics) > 0 {
-		_, ok := includeNics[nicName]
-		return ok
+		if _, ok := includeNics[nicName]; !ok {
+			return "not listed in --include-nics"
+		}
+		return ""
 	}
 
 	// 如果定义了黑名单，则排除黑名单中的接口
 	if len(excludeNics) > 0 {
 		if _, ok := excludeNics[nicName]; ok {
-			return false
+			return "listed in --exclude-nics"
 		}
 	}
 
-	return true
+	return ""
 }
 
 func InterfaceList() ([]string, error) {
 	includeNics := parseNics(flags.IncludeNics)
 	excludeNics := parseNics(flags.ExcludeNics)
 	interfaces := []string{}
-	if flags.MonthRotate != 0 {
-		vnstatData, err := getVnstatData()
-		if err == nil {
-			for interfaceName := range vnstatData {
-				if shouldInclude(interfaceName, includeNics, excludeNics) {
-					interfaces = append(interfaces, interfaceName)
-				}
-			}
-			return interfaces, nil
-		}
-	}
 	ioCounters, err := net.IOCounters(true)
 	if err != nil {
 		return nil, err
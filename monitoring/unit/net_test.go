@@ -8,10 +8,11 @@ import (
 )
 
 func TestConnectionsCount(t *testing.T) {
-	tcpCount, udpCount, err := ConnectionsCount()
+	stats, err := Connections()
 	if err != nil {
-		t.Fatalf("ConnectionsCount failed: %v", err)
+		t.Fatalf("Connections failed: %v", err)
 	}
+	tcpCount, udpCount := stats.TCP, stats.UDP
 
 	if tcpCount < 0 {
 		t.Errorf("Expected non-negative TCP count, got %d", tcpCount)
@@ -176,6 +177,27 @@ func TestShouldInclude(t *testing.T) {
 	}
 }
 
+func TestNicExcludeReason(t *testing.T) {
+	include := map[string]struct{}{"eth0": {}}
+	exclude := map[string]struct{}{"eth1": {}}
+	tests := []struct {
+		nicName  string
+		include  map[string]struct{}
+		exclude  map[string]struct{}
+		expected string
+	}{
+		{"veth1a2b3c", nil, nil, `loopback/virtual name prefix "veth"`},
+		{"wlan0", include, nil, "not listed in --include-nics"},
+		{"eth1", nil, exclude, "listed in --exclude-nics"},
+		{"eth0", include, nil, ""},
+	}
+	for _, tt := range tests {
+		if got := nicExcludeReason(tt.nicName, tt.include, tt.exclude); got != tt.expected {
+			t.Errorf("nicExcludeReason(%q) = %q, want %q", tt.nicName, got, tt.expected)
+		}
+	}
+}
+
 func TestNetworkSpeedFallback(t *testing.T) {
 	// 测试回退方法
 	includeNics := map[string]struct{}{}
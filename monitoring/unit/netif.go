@@ -0,0 +1,150 @@
+package monitoring
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// NetInterface 单个被统计网卡的本地信息
+type NetInterface struct {
+	Name      string   `json:"name"`
+	MAC       string   `json:"mac,omitempty"`
+	MTU       int      `json:"mtu"`
+	State     string   `json:"state"` // operstate：up / down / unknown / dormant / lowerlayerdown ...
+	Up        bool     `json:"up"`
+	Speed     int      `json:"speed,omitempty"`  // 协商速率，Mbps，未知时省略
+	Duplex    string   `json:"duplex,omitempty"` // full / half
+	Addresses []string `json:"addresses"`        // CIDR 形式的本地地址
+	Change    string   `json:"change,omitempty"` // 事件中的变化类型：added / removed / up / down / addresses
+}
+
+const netInterfacePollInterval = 10 * time.Second
+
+var (
+	netInterfaceMu     sync.Mutex
+	netInterfaceOnce   sync.Once
+	netInterfaceState  []NetInterface
+	netInterfaceEvents = make(chan []NetInterface, 16)
+)
+
+// NetInterfaces 返回经 --include-nics / --exclude-nics 筛选后的网卡信息，按名称排序
+func NetInterfaces() ([]NetInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	includeNics := parseNics(flags.IncludeNics)
+	excludeNics := parseNics(flags.ExcludeNics)
+	result := []NetInterface{}
+	for _, iface := range ifaces {
+		if !shouldInclude(iface.Name, includeNics, excludeNics) {
+			continue
+		}
+		n := NetInterface{Name: iface.Name, MAC: iface.HardwareAddr, MTU: iface.MTU, Addresses: []string{}}
+		for _, addr := range iface.Addrs {
+			n.Addresses = append(n.Addresses, addr.Addr)
+		}
+		sort.Strings(n.Addresses)
+		flagUp := false
+		for _, f := range iface.Flags {
+			if f == "up" {
+				flagUp = true
+			}
+		}
+		n.State, n.Speed, n.Duplex = linkInfo(iface.Name)
+		if n.State == "" {
+			n.State = "down"
+			if flagUp {
+				n.State = "up"
+			}
+		}
+		// 隧道等虚拟网卡的 operstate 常为 unknown，此时以管理状态为准
+		n.Up = flagUp && (n.State == "up" || n.State == "unknown")
+		result = append(result, n)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// StartNetInterfaceWatch 后台检查网卡状态与地址，发生变化时推送事件
+func StartNetInterfaceWatch() {
+	netInterfaceOnce.Do(func() {
+		cur, err := NetInterfaces()
+		if err != nil {
+			log.Println("Failed to list network interfaces:", err)
+		}
+		netInterfaceMu.Lock()
+		netInterfaceState = cur
+		netInterfaceMu.Unlock()
+		go func() {
+			ticker := time.NewTicker(netInterfacePollInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				pollNetInterfaces()
+			}
+		}()
+	})
+}
+
+// NetInterfaceEvents 网卡启停、出现/消失或地址变化时推送变化的网卡
+func NetInterfaceEvents() <-chan []NetInterface {
+	return netInterfaceEvents
+}
+
+func pollNetInterfaces() {
+	cur, err := NetInterfaces()
+	if err != nil {
+		return
+	}
+	netInterfaceMu.Lock()
+	prev := netInterfaceState
+	netInterfaceState = cur
+	netInterfaceMu.Unlock()
+
+	if changed := diffNetInterfaces(prev, cur); len(changed) > 0 {
+		select {
+		case netInterfaceEvents <- changed:
+		default:
+			// 通道已满时丢弃，基本信息中仍会携带最新状态
+		}
+	}
+}
+
+// diffNetInterfaces 比较两次检查结果，返回带有变化类型的网卡
+func diffNetInterfaces(prev, cur []NetInterface) []NetInterface {
+	var changed []NetInterface
+	old := map[string]NetInterface{}
+	for _, n := range prev {
+		old[n.Name] = n
+	}
+	for _, n := range cur {
+		o, ok := old[n.Name]
+		delete(old, n.Name)
+		switch {
+		case !ok:
+			n.Change = "added"
+		case o.Up && !n.Up:
+			n.Change = "down"
+		case !o.Up && n.Up:
+			n.Change = "up"
+		case strings.Join(o.Addresses, ",") != strings.Join(n.Addresses, ","):
+			n.Change = "addresses"
+		default:
+			continue
+		}
+		changed = append(changed, n)
+	}
+	for _, n := range prev {
+		if _, ok := old[n.Name]; ok {
+			n.Change, n.Up = "removed", false
+			changed = append(changed, n)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,34 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// sysClassNet 与 net.Interfaces() 一样取 agent 所在网络命名空间的网卡，不经过 --host-root
+var sysClassNet = "/sys/class/net"
+
+// linkInfo 从 sysfs 读取运行状态、协商速率与双工模式；链路断开时内核对 speed 返回 -1 或 EINVAL
+func linkInfo(name string) (state string, speed int, duplex string) {
+	dir := filepath.Join(sysClassNet, name)
+	read := func(file string) string {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+	state = read("operstate")
+	if v, err := strconv.Atoi(read("speed")); err == nil && v > 0 {
+		speed = v
+	}
+	if d := read("duplex"); d == "full" || d == "half" {
+		duplex = d
+	}
+	return state, speed, duplex
+}
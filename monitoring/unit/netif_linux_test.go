@@ -0,0 +1,42 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkInfo(t *testing.T) {
+	old := sysClassNet
+	sysClassNet = t.TempDir()
+	defer func() { sysClassNet = old }()
+
+	write := func(iface, file, content string) {
+		dir := filepath.Join(sysClassNet, iface)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("eth0", "operstate", "up\n")
+	write("eth0", "speed", "1000\n")
+	write("eth0", "duplex", "full\n")
+	write("eth1", "operstate", "down\n")
+	write("eth1", "speed", "-1\n")
+	write("eth1", "duplex", "unknown\n")
+
+	if state, speed, duplex := linkInfo("eth0"); state != "up" || speed != 1000 || duplex != "full" {
+		t.Errorf("eth0 = %s %d %s", state, speed, duplex)
+	}
+	if state, speed, duplex := linkInfo("eth1"); state != "down" || speed != 0 || duplex != "" {
+		t.Errorf("eth1 = %s %d %s", state, speed, duplex)
+	}
+	if state, _, _ := linkInfo("missing"); state != "" {
+		t.Errorf("missing interface state = %q", state)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+// linkInfo 非 Linux 平台没有 sysfs，状态由网卡标志决定
+func linkInfo(name string) (state string, speed int, duplex string) {
+	return "", 0, ""
+}
@@ -0,0 +1,42 @@
+package monitoring
+
+import "testing"
+
+func TestDiffNetInterfaces(t *testing.T) {
+	prev := []NetInterface{
+		{Name: "eth0", Up: true, Addresses: []string{"10.0.0.2/24"}},
+		{Name: "eth1", Up: true, Addresses: []string{"192.168.1.2/24"}},
+		{Name: "wg0", Up: true, Addresses: []string{"10.8.0.1/24"}},
+		{Name: "eth2", Up: false, Addresses: []string{}},
+	}
+	cur := []NetInterface{
+		{Name: "eth0", Up: true, Addresses: []string{"10.0.0.2/24"}},
+		{Name: "eth1", Up: false, Addresses: []string{"192.168.1.2/24"}},
+		{Name: "eth2", Up: true, Addresses: []string{"172.16.0.5/16"}},
+		{Name: "eth3", Up: true, Addresses: []string{"10.1.0.2/24"}},
+	}
+	changed := diffNetInterfaces(prev, cur)
+	got := map[string]string{}
+	for _, n := range changed {
+		got[n.Name] = n.Change
+	}
+	expected := map[string]string{"eth1": "down", "eth2": "up", "eth3": "added", "wg0": "removed"}
+	if len(got) != len(expected) {
+		t.Fatalf("changes = %v, want %v", got, expected)
+	}
+	for name, change := range expected {
+		if got[name] != change {
+			t.Errorf("%s change = %q, want %q", name, got[name], change)
+		}
+	}
+
+	cur[0].Addresses = []string{"10.0.0.3/24"}
+	changed = diffNetInterfaces(cur[:1], cur[:1])
+	if len(changed) != 0 {
+		t.Errorf("identical lists should not change: %+v", changed)
+	}
+	changed = diffNetInterfaces(prev[:1], cur[:1])
+	if len(changed) != 1 || changed[0].Change != "addresses" {
+		t.Errorf("address change = %+v", changed)
+	}
+}
@@ -0,0 +1,110 @@
+package monitoring
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// NicQuality 单个网卡在两次采样之间的包速率与错误、丢包速率（每秒）
+type NicQuality struct {
+	Name        string  `json:"name"`
+	PacketsSent float64 `json:"packets_sent"`
+	PacketsRecv float64 `json:"packets_recv"`
+	ErrIn       float64 `json:"errin"`
+	ErrOut      float64 `json:"errout"`
+	DropIn      float64 `json:"dropin"`
+	DropOut     float64 `json:"dropout"`
+}
+
+// NetworkQuality 网卡质量指标与全局 TCP 重传情况
+type NetworkQuality struct {
+	Interfaces     []NicQuality `json:"interfaces"`
+	TCPRetransmits float64      `json:"tcp_retransmits"`      // 重传报文数/秒
+	TCPRetransRate float64      `json:"tcp_retransmit_ratio"` // 重传报文占发送报文的百分比
+}
+
+// tcpSegments /proc/net/snmp 中 Tcp 行的发送与重传报文计数
+type tcpSegments struct {
+	OutSegs     uint64
+	RetransSegs uint64
+}
+
+type netQualitySample struct {
+	at       time.Time
+	counters map[string]net.IOCountersStat
+	tcp      tcpSegments
+	tcpOK    bool
+}
+
+var (
+	netQualityMu   sync.Mutex
+	netQualityPrev *netQualitySample
+)
+
+// NetworkQualityStats 与上一次调用的采样比较，速率覆盖整个上报间隔，偶发的错误和丢包不会被 1 秒窗口漏掉
+// 首次调用只建立基线，返回的速率均为 0
+func NetworkQualityStats() (NetworkQuality, error) {
+	counters, err := net.IOCounters(true)
+	if err != nil {
+		return NetworkQuality{Interfaces: []NicQuality{}}, fmt.Errorf("failed to get network IO counters: %w", err)
+	}
+	cur := &netQualitySample{at: time.Now(), counters: map[string]net.IOCountersStat{}}
+	for _, c := range counters {
+		cur.counters[c.Name] = c
+	}
+	cur.tcp, cur.tcpOK = readTCPSegments()
+
+	netQualityMu.Lock()
+	prev := netQualityPrev
+	netQualityPrev = cur
+	netQualityMu.Unlock()
+
+	return compareNetQuality(prev, cur, parseNics(flags.IncludeNics), parseNics(flags.ExcludeNics)), nil
+}
+
+func compareNetQuality(prev, cur *netQualitySample, includeNics, excludeNics map[string]struct{}) NetworkQuality {
+	q := NetworkQuality{Interfaces: []NicQuality{}}
+	var seconds float64
+	if prev != nil {
+		seconds = cur.at.Sub(prev.at).Seconds()
+	}
+	rate := func(last, now uint64) float64 {
+		if seconds <= 0 {
+			return 0
+		}
+		return float64(counterDelta(last, now, false)) / seconds
+	}
+
+	for name, c := range cur.counters {
+		if !shouldInclude(name, includeNics, excludeNics) {
+			continue
+		}
+		n := NicQuality{Name: name}
+		if prev != nil {
+			if p, ok := prev.counters[name]; ok {
+				n.PacketsSent = rate(p.PacketsSent, c.PacketsSent)
+				n.PacketsRecv = rate(p.PacketsRecv, c.PacketsRecv)
+				n.ErrIn = rate(p.Errin, c.Errin)
+				n.ErrOut = rate(p.Errout, c.Errout)
+				n.DropIn = rate(p.Dropin, c.Dropin)
+				n.DropOut = rate(p.Dropout, c.Dropout)
+			}
+		}
+		q.Interfaces = append(q.Interfaces, n)
+	}
+	sort.Slice(q.Interfaces, func(i, j int) bool { return q.Interfaces[i].Name < q.Interfaces[j].Name })
+
+	if prev != nil && prev.tcpOK && cur.tcpOK {
+		retrans := counterDelta(prev.tcp.RetransSegs, cur.tcp.RetransSegs, false)
+		q.TCPRetransmits = rate(prev.tcp.RetransSegs, cur.tcp.RetransSegs)
+		if out := counterDelta(prev.tcp.OutSegs, cur.tcp.OutSegs, false); out > 0 {
+			q.TCPRetransRate = float64(retrans) / float64(out) * 100
+		}
+	}
+	return q
+}
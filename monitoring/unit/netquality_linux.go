@@ -0,0 +1,51 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// readTCPSegments 读取 /proc/net/snmp，Tcp 段由一行字段名和一行数值组成
+func readTCPSegments() (tcpSegments, bool) {
+	data, err := os.ReadFile(hostPath("proc", "net", "snmp"))
+	if err != nil {
+		return tcpSegments{}, false
+	}
+	return parseNetSnmp(string(data))
+}
+
+func parseNetSnmp(data string) (tcpSegments, bool) {
+	var header []string
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "Tcp:" {
+			continue
+		}
+		if header == nil {
+			header = fields
+			continue
+		}
+		var seg tcpSegments
+		found := 0
+		for i := 1; i < len(fields) && i < len(header); i++ {
+			v, err := strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch header[i] {
+			case "OutSegs":
+				seg.OutSegs = v
+				found++
+			case "RetransSegs":
+				seg.RetransSegs = v
+				found++
+			}
+		}
+		return seg, found == 2
+	}
+	return tcpSegments{}, false
+}
@@ -0,0 +1,23 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import "testing"
+
+func TestParseNetSnmp(t *testing.T) {
+	data := `Ip: Forwarding DefaultTTL InReceives
+Ip: 1 64 123456
+Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens AttemptFails EstabResets CurrEstab InSegs OutSegs RetransSegs InErrs OutRsts InCsumErrors
+Tcp: 1 200 120000 -1 5000 3000 10 20 15 987654 876543 1234 0 55 0
+Udp: InDatagrams NoPorts InErrors OutDatagrams
+Udp: 100 2 0 100
+`
+	seg, ok := parseNetSnmp(data)
+	if !ok || seg.OutSegs != 876543 || seg.RetransSegs != 1234 {
+		t.Errorf("parseNetSnmp = %+v, %v", seg, ok)
+	}
+	if _, ok := parseNetSnmp("Ip: Forwarding\nIp: 1\n"); ok {
+		t.Error("missing Tcp section should fail")
+	}
+}
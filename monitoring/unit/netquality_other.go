@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+// readTCPSegments 非 Linux 平台不统计 TCP 重传
+func readTCPSegments() (tcpSegments, bool) {
+	return tcpSegments{}, false
+}
@@ -0,0 +1,53 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+func TestCompareNetQuality(t *testing.T) {
+	at := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	prev := &netQualitySample{
+		at: at,
+		counters: map[string]net.IOCountersStat{
+			"eth0": {Name: "eth0", PacketsSent: 1000, PacketsRecv: 2000, Errin: 5, Dropin: 10},
+			"lo":   {Name: "lo", PacketsSent: 100, PacketsRecv: 100},
+		},
+		tcp:   tcpSegments{OutSegs: 10000, RetransSegs: 50},
+		tcpOK: true,
+	}
+	cur := &netQualitySample{
+		at: at.Add(10 * time.Second),
+		counters: map[string]net.IOCountersStat{
+			"eth0": {Name: "eth0", PacketsSent: 1500, PacketsRecv: 3000, Errin: 5, Dropin: 30, Dropout: 1},
+			"eth1": {Name: "eth1", PacketsSent: 10, PacketsRecv: 10},
+			"lo":   {Name: "lo", PacketsSent: 200, PacketsRecv: 200},
+		},
+		tcp:   tcpSegments{OutSegs: 12000, RetransSegs: 90},
+		tcpOK: true,
+	}
+
+	q := compareNetQuality(prev, cur, nil, nil)
+	if len(q.Interfaces) != 2 || q.Interfaces[0].Name != "eth0" || q.Interfaces[1].Name != "eth1" {
+		t.Fatalf("interfaces = %+v", q.Interfaces)
+	}
+	eth0 := q.Interfaces[0]
+	if eth0.PacketsSent != 50 || eth0.PacketsRecv != 100 || eth0.ErrIn != 0 || eth0.DropIn != 2 || eth0.DropOut != 0.1 {
+		t.Errorf("eth0 = %+v", eth0)
+	}
+	// 新出现的网卡没有上一次采样，速率为 0
+	if eth1 := q.Interfaces[1]; eth1.PacketsSent != 0 {
+		t.Errorf("eth1 = %+v", eth1)
+	}
+	if q.TCPRetransmits != 4 || q.TCPRetransRate != 2 {
+		t.Errorf("retransmits = %v/s, ratio %v%%", q.TCPRetransmits, q.TCPRetransRate)
+	}
+
+	// 首次采样只建立基线
+	q = compareNetQuality(nil, cur, nil, nil)
+	if q.Interfaces[0].PacketsSent != 0 || q.TCPRetransmits != 0 {
+		t.Errorf("baseline = %+v", q)
+	}
+}
@@ -26,7 +26,7 @@ func OSName() string {
 		return synologyName
 	}
 
-	file, err := os.Open("/etc/os-release")
+	file, err := os.Open(hostPath("etc/os-release"))
 	if err != nil {
 		return "Linux"
 	}
@@ -49,8 +49,8 @@ func OSName() string {
 
 func detectSynology() string {
 	synologyFiles := []string{
-		"/etc/synoinfo.conf",
-		"/etc.defaults/synoinfo.conf",
+		hostPath("etc/synoinfo.conf"),
+		hostPath("etc.defaults/synoinfo.conf"),
 	}
 
 	for _, file := range synologyFiles {
@@ -61,7 +61,7 @@ func detectSynology() string {
 		}
 	}
 
-	if info, err := os.Stat("/usr/syno"); err == nil && info.IsDir() {
+	if info, err := os.Stat(hostPath("usr/syno")); err == nil && info.IsDir() {
 		return "Synology DSM"
 	}
 
@@ -141,7 +141,7 @@ func detectProxmoxVE() string {
 	}
 
 	if version != "" {
-		if file, err := os.Open("/etc/os-release"); err == nil {
+		if file, err := os.Open(hostPath("etc/os-release")); err == nil {
 			defer file.Close()
 			scanner := bufio.NewScanner(file)
 			for scanner.Scan() {
@@ -197,7 +197,7 @@ func detectAndroid() string {
 	}
 
 	// 2. Try to check Android system build.prop file
-	if _, err := os.Stat("/system/build.prop"); err == nil {
+	if _, err := os.Stat(hostPath("system/build.prop")); err == nil {
 		return readAndroidBuildProp()
 	}
 
@@ -211,7 +211,7 @@ func detectAndroid() string {
 
 // readAndroidBuildProp reads Android version information from build.prop file
 func readAndroidBuildProp() string {
-	file, err := os.Open("/system/build.prop")
+	file, err := os.Open(hostPath("system/build.prop"))
 	if err != nil {
 		return "Android"
 	}
@@ -257,10 +257,10 @@ func readAndroidBuildProp() string {
 // isAndroidSystem determines if the system is Android by checking typical directory structure
 func isAndroidSystem() bool {
 	androidDirs := []string{
-		"/system/app",
-		"/system/priv-app",
-		"/data/app",
-		"/sdcard",
+		hostPath("system/app"),
+		hostPath("system/priv-app"),
+		hostPath("data/app"),
+		hostPath("sdcard"),
 	}
 
 	dirCount := 0
@@ -0,0 +1,226 @@
+package monitoring
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+// StoragePool ZFS 池或 Btrfs 文件系统，多个数据集/子卷共享同一份空间，只统计一次
+type StoragePool struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"` // zfs / btrfs
+	Size        uint64   `json:"size"`
+	Allocated   uint64   `json:"allocated"`
+	Free        uint64   `json:"free"`
+	Health      string   `json:"health,omitempty"` // ZFS: ONLINE / DEGRADED / ...；Btrfs: OK / ERRORS
+	Scrub       string   `json:"scrub,omitempty"`
+	Mountpoints []string `json:"mountpoints"`
+}
+
+const (
+	// zpool status / btrfs scrub status 开销较大，按此间隔缓存
+	poolCacheTTL   = time.Minute
+	poolCmdTimeout = 10 * time.Second
+)
+
+var (
+	poolMu       sync.Mutex
+	poolCache    []StoragePool
+	poolCachedAt time.Time
+)
+
+// isPoolFilesystem 由池统一管理空间的文件系统，不按挂载点逐个统计
+func isPoolFilesystem(part disk.PartitionStat) bool {
+	fstype := strings.ToLower(part.Fstype)
+	return fstype == "zfs" || fstype == "btrfs"
+}
+
+// StoragePools 将 ZFS 数据集按池、Btrfs 子卷按设备归并，返回每个池的容量与健康状态
+func StoragePools(parts []disk.PartitionStat) []StoragePool {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	if poolCache != nil && time.Since(poolCachedAt) < poolCacheTTL {
+		return poolCache
+	}
+
+	pools := groupStoragePools(parts)
+	var health map[string]string
+	for i := range pools {
+		p := &pools[i]
+		switch p.Type {
+		case "zfs":
+			if health == nil {
+				health = zpoolHealth()
+			}
+			// zpool list 的 SIZE/ALLOC 是含 raidz 校验的原始容量，可用空间以 zfs list 为准
+			if used, avail, ok := parseZfsUsage(runPoolCmd("zfs", "list", "-Hp", "-o", "used,avail", p.Name)); ok {
+				p.Size, p.Allocated, p.Free = used+avail, used, avail
+			} else {
+				fillPoolUsage(p)
+			}
+			if h, ok := health[p.Name]; ok {
+				p.Health = h
+				p.Scrub = parseZpoolScan(runPoolCmd("zpool", "status", p.Name))
+			}
+		case "btrfs":
+			fillPoolUsage(p)
+			mp := p.Mountpoints[0]
+			if isHostRootSet() {
+				mp = hostPath(mp)
+			}
+			p.Health = btrfsHealth(mp)
+			p.Scrub = parseBtrfsScrub(runPoolCmd("btrfs", "scrub", "status", mp))
+		}
+	}
+	poolCache = pools
+	poolCachedAt = time.Now()
+	return pools
+}
+
+// groupStoragePools 按池名（ZFS 数据集名的第一段）或设备（Btrfs）归并挂载点，
+// 与普通分区相同的排除规则同样适用，例如 /var/lib/docker 下的数据集
+func groupStoragePools(parts []disk.PartitionStat) []StoragePool {
+	pools := []StoragePool{}
+	index := map[string]int{}
+	for _, part := range parts {
+		if !isPoolFilesystem(part) || !isPhysicalDisk(part) {
+			continue
+		}
+		fstype := strings.ToLower(part.Fstype)
+		name := part.Device
+		if fstype == "zfs" {
+			name, _, _ = strings.Cut(part.Device, "/")
+		}
+		key := fstype + ":" + name
+		if i, ok := index[key]; ok {
+			pools[i].Mountpoints = append(pools[i].Mountpoints, part.Mountpoint)
+			continue
+		}
+		index[key] = len(pools)
+		pools = append(pools, StoragePool{Name: name, Type: fstype, Mountpoints: []string{part.Mountpoint}})
+	}
+	for i := range pools {
+		// 挂载点最短的通常是池根或顶层子卷，用它来统计容量
+		sort.SliceStable(pools[i].Mountpoints, func(a, b int) bool {
+			return len(pools[i].Mountpoints[a]) < len(pools[i].Mountpoints[b])
+		})
+	}
+	return pools
+}
+
+// fillPoolUsage 无法通过池工具获取时，回退到 statfs，池内所有挂载点看到的容量相同
+func fillPoolUsage(p *StoragePool) {
+	for _, mp := range p.Mountpoints {
+		if u, err := diskUsage(mp); err == nil {
+			p.Size, p.Allocated, p.Free = u.Total, u.Used, u.Free
+			return
+		}
+	}
+}
+
+func runPoolCmd(name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), poolCmdTimeout)
+	defer cancel()
+	out, _ := exec.CommandContext(ctx, name, args...).Output()
+	return string(out)
+}
+
+// zpoolHealth 读取所有池的健康状态
+func zpoolHealth() map[string]string {
+	return parseZpoolHealth(runPoolCmd("zpool", "list", "-H", "-o", "name,health"))
+}
+
+func parseZpoolHealth(output string) map[string]string {
+	health := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		f := strings.Split(scanner.Text(), "\t")
+		if len(f) != 2 {
+			continue
+		}
+		health[f[0]] = f[1]
+	}
+	return health
+}
+
+// parseZfsUsage 解析 zfs list -Hp -o used,avail 的输出，即池根数据集扣除校验与保留空间后的用量
+func parseZfsUsage(output string) (used, avail uint64, ok bool) {
+	f := strings.Split(strings.TrimSpace(output), "\t")
+	if len(f) != 2 {
+		return 0, 0, false
+	}
+	used, err1 := strconv.ParseUint(f[0], 10, 64)
+	avail, err2 := strconv.ParseUint(f[1], 10, 64)
+	return used, avail, err1 == nil && err2 == nil
+}
+
+// parseZpoolScan 取 zpool status 中 scan: 一行，进行中时附带完成百分比
+func parseZpoolScan(output string) string {
+	scan := ""
+	inScan := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if v, ok := strings.CutPrefix(line, "scan:"); ok {
+			scan = strings.TrimSpace(v)
+			inScan = true
+			continue
+		}
+		if !inScan {
+			continue
+		}
+		if strings.Contains(line, ":") && !strings.Contains(line, "done") {
+			break // 下一个字段
+		}
+		if i := strings.Index(line, "% done"); i >= 0 {
+			scan += ", " + strings.TrimSpace(line[strings.LastIndex(line[:i], " ")+1:])
+			break
+		}
+	}
+	return scan
+}
+
+// btrfsHealth 通过 btrfs device stats -c 检查设备错误计数，工具不可用时返回空
+func btrfsHealth(mountpoint string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), poolCmdTimeout)
+	defer cancel()
+	err := exec.CommandContext(ctx, "btrfs", "device", "stats", "-c", mountpoint).Run()
+	if err == nil {
+		return "OK"
+	}
+	// -c：存在非零错误计数时以 64 位退出
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode()&64 != 0 {
+		return "ERRORS"
+	}
+	return ""
+}
+
+// parseBtrfsScrub 解析 btrfs scrub status 的状态、开始时间与错误汇总
+func parseBtrfsScrub(output string) string {
+	var parts []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		k, v, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		switch strings.TrimSpace(k) {
+		case "Status":
+			parts = append([]string{v}, parts...)
+		case "Scrub started":
+			parts = append(parts, "started "+v)
+		case "Error summary":
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
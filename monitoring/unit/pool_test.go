@@ -0,0 +1,90 @@
+package monitoring
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/disk"
+)
+
+func TestGroupStoragePools(t *testing.T) {
+	parts := []disk.PartitionStat{
+		{Device: "/dev/sda1", Mountpoint: "/boot", Fstype: "ext4"},
+		{Device: "tank/data/media", Mountpoint: "/tank/data/media", Fstype: "zfs"},
+		{Device: "tank", Mountpoint: "/tank", Fstype: "zfs"},
+		{Device: "tank/data", Mountpoint: "/tank/data", Fstype: "zfs"},
+		{Device: "tank/docker/3f2a9c", Mountpoint: "/var/lib/docker/zfs/graph/3f2a9c", Fstype: "zfs"},
+		{Device: "rpool/ROOT/debian", Mountpoint: "/", Fstype: "zfs"},
+		{Device: "/dev/nvme0n1p2", Mountpoint: "/home", Fstype: "btrfs"},
+		{Device: "/dev/nvme0n1p2", Mountpoint: "/srv", Fstype: "btrfs"},
+	}
+	pools := groupStoragePools(parts)
+	if len(pools) != 3 {
+		t.Fatalf("expected 3 pools, got %+v", pools)
+	}
+	if pools[0].Name != "tank" || pools[0].Type != "zfs" ||
+		!reflect.DeepEqual(pools[0].Mountpoints, []string{"/tank", "/tank/data", "/tank/data/media"}) {
+		t.Errorf("unexpected tank pool: %+v", pools[0])
+	}
+	if pools[1].Name != "rpool" || !reflect.DeepEqual(pools[1].Mountpoints, []string{"/"}) {
+		t.Errorf("unexpected rpool: %+v", pools[1])
+	}
+	if pools[2].Name != "/dev/nvme0n1p2" || pools[2].Type != "btrfs" || len(pools[2].Mountpoints) != 2 {
+		t.Errorf("unexpected btrfs pool: %+v", pools[2])
+	}
+}
+
+func TestParseZpoolHealth(t *testing.T) {
+	health := parseZpoolHealth("tank\tONLINE\nrpool\tDEGRADED\n")
+	if health["tank"] != "ONLINE" || health["rpool"] != "DEGRADED" || len(health) != 2 {
+		t.Errorf("unexpected health: %+v", health)
+	}
+}
+
+func TestParseZfsUsage(t *testing.T) {
+	used, avail, ok := parseZfsUsage("813370028032\t1843795705856\n")
+	if !ok || used != 813370028032 || avail != 1843795705856 {
+		t.Errorf("unexpected usage: %d %d %v", used, avail, ok)
+	}
+	if _, _, ok := parseZfsUsage("cannot open 'tank': dataset does not exist\n"); ok {
+		t.Error("expected error output to be rejected")
+	}
+}
+
+func TestParseZpoolScan(t *testing.T) {
+	done := `  pool: tank
+ state: ONLINE
+  scan: scrub repaired 0B in 00:01:02 with 0 errors on Sun Oct 12 00:25:03 2025
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+`
+	if got := parseZpoolScan(done); got != "scrub repaired 0B in 00:01:02 with 0 errors on Sun Oct 12 00:25:03 2025" {
+		t.Errorf("unexpected finished scan: %q", got)
+	}
+
+	running := `  pool: tank
+ state: ONLINE
+  scan: scrub in progress since Sun Oct 12 00:24:01 2025
+	1.23T / 2.00T scanned at 1.2G/s, 800G / 2.00T issued at 900M/s
+	0B repaired, 40.00% done, 00:20:00 to go
+config:
+`
+	if got := parseZpoolScan(running); got != "scrub in progress since Sun Oct 12 00:24:01 2025, 40.00% done, 00:20:00 to go" {
+		t.Errorf("unexpected running scan: %q", got)
+	}
+}
+
+func TestParseBtrfsScrub(t *testing.T) {
+	output := `UUID:             4f8c7a6e-1b2d-4c3e-9f0a-123456789abc
+Scrub started:    Sun Oct 12 03:00:01 2025
+Status:           finished
+Duration:         0:12:34
+Total to scrub:   512.00GiB
+Rate:             695.12MiB/s
+Error summary:    no errors found
+`
+	if got := parseBtrfsScrub(output); got != "finished, started Sun Oct 12 03:00:01 2025, no errors found" {
+		t.Errorf("unexpected scrub status: %q", got)
+	}
+}
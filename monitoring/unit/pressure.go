@@ -0,0 +1,60 @@
+package monitoring
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// PressureStat PSI 中一行 some/full 的统计：最近 10/60/300 秒内任务因资源不足而停顿的时间占比，total 为累计停顿微秒数
+type PressureStat struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+	Total  uint64  `json:"total"`
+}
+
+// PressureInfo 单个资源的 PSI：some 为至少一个任务停顿，full 为所有非空闲任务同时停顿
+type PressureInfo struct {
+	Some PressureStat  `json:"some"`
+	Full *PressureStat `json:"full,omitempty"`
+}
+
+// parsePressure 解析 /proc/pressure/{cpu,memory,io} 的内容
+func parsePressure(data string) (PressureInfo, bool) {
+	var info PressureInfo
+	found := false
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var st PressureStat
+		for _, f := range fields[1:] {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok {
+				continue
+			}
+			switch k {
+			case "avg10":
+				st.Avg10, _ = strconv.ParseFloat(v, 64)
+			case "avg60":
+				st.Avg60, _ = strconv.ParseFloat(v, 64)
+			case "avg300":
+				st.Avg300, _ = strconv.ParseFloat(v, 64)
+			case "total":
+				st.Total, _ = strconv.ParseUint(v, 10, 64)
+			}
+		}
+		switch fields[0] {
+		case "some":
+			info.Some = st
+			found = true
+		case "full":
+			full := st
+			info.Full = &full
+		}
+	}
+	return info, found
+}
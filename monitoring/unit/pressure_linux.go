@@ -0,0 +1,21 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import "os"
+
+// Pressure 读取 /proc/pressure 下 cpu、memory、io 的 PSI，内核未启用 PSI（CONFIG_PSI 或 psi=0）时返回 false
+func Pressure() (map[string]PressureInfo, bool) {
+	result := map[string]PressureInfo{}
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		data, err := os.ReadFile(hostPath("proc", "pressure", resource))
+		if err != nil {
+			continue
+		}
+		if info, ok := parsePressure(string(data)); ok {
+			result[resource] = info
+		}
+	}
+	return result, len(result) > 0
+}
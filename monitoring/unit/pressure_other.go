@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+// Pressure PSI 仅 Linux 提供
+func Pressure() (map[string]PressureInfo, bool) {
+	return nil, false
+}
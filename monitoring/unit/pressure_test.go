@@ -0,0 +1,25 @@
+package monitoring
+
+import "testing"
+
+func TestParsePressure(t *testing.T) {
+	info, ok := parsePressure("some avg10=1.50 avg60=0.75 avg300=0.20 total=123456\nfull avg10=0.50 avg60=0.25 avg300=0.05 total=6789\n")
+	if !ok {
+		t.Fatal("expected pressure to parse")
+	}
+	if info.Some != (PressureStat{Avg10: 1.5, Avg60: 0.75, Avg300: 0.2, Total: 123456}) {
+		t.Errorf("some = %+v", info.Some)
+	}
+	if info.Full == nil || info.Full.Avg10 != 0.5 || info.Full.Total != 6789 {
+		t.Errorf("full = %+v", info.Full)
+	}
+
+	// 5.13 之前的内核 cpu 只有 some 行
+	info, ok = parsePressure("some avg10=0.00 avg60=0.00 avg300=0.00 total=0\n")
+	if !ok || info.Full != nil {
+		t.Errorf("cpu pressure = %+v, %v", info, ok)
+	}
+	if _, ok := parsePressure(""); ok {
+		t.Error("empty pressure should fail")
+	}
+}
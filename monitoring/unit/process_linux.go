@@ -13,9 +13,9 @@ func ProcessCount() (count int) {
 	return processCountLinux()
 }
 
-// processCountLinux counts processes by reading /proc directory
+// processCountLinux counts processes by reading the host /proc directory
 func processCountLinux() (count int) {
-	procDir := "/proc"
+	procDir := hostPath("proc")
 
 	entries, err := os.ReadDir(procDir)
 	if err != nil {
@@ -0,0 +1,123 @@
+package monitoring
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ProcessInfo 单个进程的资源占用，CPU 为占用单核的百分比
+type ProcessInfo struct {
+	PID     int32   `json:"pid"`
+	Name    string  `json:"name"`
+	User    string  `json:"user"`
+	Cmdline string  `json:"cmdline"`
+	CPU     float64 `json:"cpu"`
+	RSS     uint64  `json:"rss"`
+}
+
+// processSample 一次采样中单个进程的原始数据
+type processSample struct {
+	pid       int32
+	name      string
+	cpuTime   float64 // 累计 CPU 时间（秒）
+	rss       uint64
+	startTime uint64 // 用于识别 PID 复用
+}
+
+// key pid 与启动时间，PID 被复用时也能区分不同进程
+func (s processSample) key() string {
+	return strconv.Itoa(int(s.pid)) + ":" + strconv.FormatUint(s.startTime, 10)
+}
+
+const (
+	// 两次采样的最小间隔，期间直接返回缓存结果，避免在进程很多的主机上频繁遍历
+	processTopMinInterval = 5 * time.Second
+	processCmdlineMaxLen  = 256
+)
+
+var (
+	processTopMu      sync.Mutex
+	processTopPrev    map[int32]processSample
+	processTopPrevAt  time.Time
+	processTopByCPU   []ProcessInfo
+	processTopByMem   []ProcessInfo
+	processTopCachedN int
+)
+
+// TopProcesses 返回按 CPU 和按 RSS 排序的前 n 个进程。
+// CPU 使用率由相邻两次采样的差值计算，首次调用时均为 0。
+func TopProcesses(n int) (byCPU, byMem []ProcessInfo, err error) {
+	processTopMu.Lock()
+	defer processTopMu.Unlock()
+
+	now := time.Now()
+	if processTopPrev != nil && n == processTopCachedN && now.Sub(processTopPrevAt) < processTopMinInterval {
+		return processTopByCPU, processTopByMem, nil
+	}
+
+	samples, err := sampleProcesses()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	elapsed := now.Sub(processTopPrevAt).Seconds()
+	infos := make([]ProcessInfo, 0, len(samples))
+	current := make(map[int32]processSample, len(samples))
+	for _, s := range samples {
+		current[s.pid] = s
+		info := ProcessInfo{PID: s.pid, Name: s.name, RSS: s.rss}
+		if prev, ok := processTopPrev[s.pid]; ok && prev.startTime == s.startTime && elapsed > 0 {
+			if delta := s.cpuTime - prev.cpuTime; delta > 0 {
+				info.CPU = delta / elapsed * 100
+			}
+		}
+		infos = append(infos, info)
+	}
+	processTopPrev = current
+	processTopPrevAt = now
+
+	byCPU = topProcessesBy(infos, n, func(a, b ProcessInfo) bool { return a.CPU > b.CPU })
+	byMem = topProcessesBy(infos, n, func(a, b ProcessInfo) bool { return a.RSS > b.RSS })
+
+	// 只为入选的进程读取用户和命令行
+	details := map[int32]ProcessInfo{}
+	for _, list := range [][]ProcessInfo{byCPU, byMem} {
+		for i := range list {
+			d, ok := details[list[i].PID]
+			if !ok {
+				d.User, d.Cmdline = processUser(list[i].PID), processCmdline(list[i].PID)
+				details[list[i].PID] = d
+			}
+			list[i].User = d.User
+			list[i].Cmdline = truncateCmdline(d.Cmdline)
+		}
+	}
+
+	processTopByCPU, processTopByMem, processTopCachedN = byCPU, byMem, n
+	return byCPU, byMem, nil
+}
+
+func topProcessesBy(infos []ProcessInfo, n int, less func(a, b ProcessInfo) bool) []ProcessInfo {
+	sorted := make([]ProcessInfo, len(infos))
+	copy(sorted, infos)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// truncateCmdline 按字节截断过长的命令行，截断点回退到字符边界，避免切开多字节字符
+func truncateCmdline(cmdline string) string {
+	if len(cmdline) <= processCmdlineMaxLen {
+		return cmdline
+	}
+	cut := processCmdlineMaxLen
+	for cut > 0 && !utf8.RuneStart(cmdline[cut]) {
+		cut--
+	}
+	return cmdline[:cut] + "..."
+}
@@ -0,0 +1,150 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Linux 上 USER_HZ 固定为 100
+const clockTicksPerSecond = 100
+
+var (
+	usernameCache   = map[string]string{}
+	usernameCacheMu sync.Mutex
+)
+
+// sampleProcesses 只读取 /proc/[pid]/stat，每个进程一次小文件读取
+func sampleProcesses() ([]processSample, error) {
+	entries, err := os.ReadDir(hostPath("proc"))
+	if err != nil {
+		return nil, err
+	}
+	pageSize := uint64(os.Getpagesize())
+	samples := make([]processSample, 0, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(hostPath("proc", entry.Name(), "stat"))
+		if err != nil {
+			continue // 进程可能已退出
+		}
+		s, err := parseProcStat(data, pageSize)
+		if err != nil {
+			continue
+		}
+		s.pid = int32(pid)
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+// parseProcStat 解析 /proc/[pid]/stat，进程名可能包含空格和括号，以最后一个 ')' 为界
+func parseProcStat(data []byte, pageSize uint64) (processSample, error) {
+	open := bytes.IndexByte(data, '(')
+	end := bytes.LastIndexByte(data, ')')
+	if open < 0 || end < open {
+		return processSample{}, errors.New("malformed stat")
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	// fields[0] 为第 3 个字段 state
+	if len(fields) < 22 {
+		return processSample{}, errors.New("malformed stat")
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	start, _ := strconv.ParseUint(fields[19], 10, 64)
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+	if rss < 0 {
+		rss = 0
+	}
+	return processSample{
+		name:      string(data[open+1 : end]),
+		cpuTime:   float64(utime+stime) / clockTicksPerSecond,
+		rss:       uint64(rss) * pageSize,
+		startTime: start,
+	}, nil
+}
+
+// processCmdline 读取进程命令行，参数间以空格分隔
+func processCmdline(pid int32) string {
+	data, err := os.ReadFile(hostPath("proc", strconv.Itoa(int(pid)), "cmdline"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bytes.ReplaceAll(data, []byte{0}, []byte{' '})))
+}
+
+// processUser 从 /proc/[pid]/status 读取真实 UID 并转换为用户名
+func processUser(pid int32) string {
+	file, err := os.Open(hostPath("proc", strconv.Itoa(int(pid)), "status"))
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Uid:") {
+			if f := strings.Fields(line); len(f) >= 2 {
+				return lookupUsername(f[1])
+			}
+		}
+	}
+	return ""
+}
+
+// processStartedAt 由开机时间和 starttime（clock ticks）计算进程启动时间，boot 由调用方每轮读取一次
+func processStartedAt(s processSample, boot uint64) time.Time {
+	if boot == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(boot), 0).Add(time.Duration(s.startTime) * time.Second / clockTicksPerSecond)
+}
+
+func lookupUsername(uid string) string {
+	usernameCacheMu.Lock()
+	defer usernameCacheMu.Unlock()
+	if name, ok := usernameCache[uid]; ok {
+		return name
+	}
+	name := uid
+	if isHostRootSet() {
+		// 宿主机进程的用户以宿主机的 /etc/passwd 为准
+		if u := passwdUsername(hostPath("etc/passwd"), uid); u != "" {
+			name = u
+		}
+	} else if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	usernameCache[uid] = name
+	return name
+}
+
+// passwdUsername 在 passwd 文件中查找 uid 对应的用户名
+func passwdUsername(path, uid string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		f := strings.Split(scanner.Text(), ":")
+		if len(f) >= 3 && f[2] == uid {
+			return f[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import "testing"
+
+func TestParseProcStat(t *testing.T) {
+	stat := []byte("1234 (my (weird) proc) S 1 1234 1234 0 -1 4194560 100 0 0 0 250 50 0 0 20 0 1 0 98765 1000000 512 18446744073709551615")
+	s, err := parseProcStat(stat, 4096)
+	if err != nil {
+		t.Fatalf("parseProcStat failed: %v", err)
+	}
+	if s.name != "my (weird) proc" {
+		t.Errorf("name = %q, want %q", s.name, "my (weird) proc")
+	}
+	if s.cpuTime != 3.0 {
+		t.Errorf("cpuTime = %v, want 3.0", s.cpuTime)
+	}
+	if s.startTime != 98765 {
+		t.Errorf("startTime = %d, want 98765", s.startTime)
+	}
+	if s.rss != 512*4096 {
+		t.Errorf("rss = %d, want %d", s.rss, 512*4096)
+	}
+
+	if _, err := parseProcStat([]byte("1234 (short) S 1"), 4096); err == nil {
+		t.Error("expected error for truncated stat")
+	}
+}
+
+func TestTopProcesses(t *testing.T) {
+	byCPU, byMem, err := TopProcesses(5)
+	if err != nil {
+		t.Fatalf("TopProcesses failed: %v", err)
+	}
+	if len(byMem) == 0 || len(byMem) > 5 || len(byCPU) > 5 {
+		t.Fatalf("unexpected result sizes: cpu=%d mem=%d", len(byCPU), len(byMem))
+	}
+	for i := 1; i < len(byMem); i++ {
+		if byMem[i].RSS > byMem[i-1].RSS {
+			t.Errorf("byMem not sorted at %d", i)
+		}
+	}
+	t.Logf("top by memory: %+v", byMem)
+}
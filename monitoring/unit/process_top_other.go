@@ -0,0 +1,62 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+import (
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// sampleProcesses 非 Linux 平台通过 gopsutil 采样
+func sampleProcesses() ([]processSample, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]processSample, 0, len(procs))
+	for _, p := range procs {
+		s := processSample{pid: p.Pid}
+		if times, err := p.Times(); err == nil {
+			s.cpuTime = times.User + times.System
+		}
+		if mem, err := p.MemoryInfo(); err == nil {
+			s.rss = mem.RSS
+		}
+		if created, err := p.CreateTime(); err == nil {
+			s.startTime = uint64(created)
+		}
+		s.name, _ = p.Name()
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+// processCmdline 读取进程命令行
+func processCmdline(pid int32) string {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return ""
+	}
+	cmdline, _ := p.Cmdline()
+	return cmdline
+}
+
+// processUser 读取进程所属用户名
+func processUser(pid int32) string {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return ""
+	}
+	username, _ := p.Username()
+	return username
+}
+
+// processStartedAt startTime 为 gopsutil 返回的毫秒时间戳，不需要开机时间
+func processStartedAt(s processSample, _ uint64) time.Time {
+	if s.startTime == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(s.startTime))
+}
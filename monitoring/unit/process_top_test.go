@@ -0,0 +1,24 @@
+package monitoring
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateCmdline(t *testing.T) {
+	short := "/usr/bin/python3 app.py"
+	if got := truncateCmdline(short); got != short {
+		t.Errorf("short cmdline changed: %q", got)
+	}
+
+	// 多字节字符跨越截断点时整体丢弃
+	cmdline := strings.Repeat("a", processCmdlineMaxLen-1) + "中文参数"
+	got := truncateCmdline(cmdline)
+	if !utf8.ValidString(got) {
+		t.Fatalf("truncated cmdline is not valid UTF-8: %q", got)
+	}
+	if want := strings.Repeat("a", processCmdlineMaxLen-1) + "..."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
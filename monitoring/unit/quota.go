@@ -0,0 +1,344 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// TrafficQuota 一条流量配额：统计对象为单个网卡或全部被统计网卡（total）
+type TrafficQuota struct {
+	Interface string // 网卡名，total 表示 --include-nics / --exclude-nics 筛选后的合计
+	Direction string // in / out / sum / max，与服务商的计费方式对应
+	Limit     uint64
+}
+
+// Name 配额的唯一标识，形如 eth0:out、total:sum
+func (q TrafficQuota) Name() string {
+	return q.Interface + ":" + q.Direction
+}
+
+// QuotaStatus 当前计费周期内一条配额的使用情况
+type QuotaStatus struct {
+	Name        string    `json:"name"`
+	Interface   string    `json:"interface"`
+	Direction   string    `json:"direction"`
+	Limit       uint64    `json:"limit"`
+	Used        uint64    `json:"used"`
+	Percent     float64   `json:"percent"`
+	Projected   uint64    `json:"projected"` // 按本周期至今的平均速率推算的周期末用量
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Exceeded    bool      `json:"exceeded"`
+	Threshold   float64   `json:"threshold,omitempty"` // 事件中携带本次越过的告警阈值
+}
+
+const (
+	quotaPollInterval  = time.Minute
+	quotaActionTimeout = 5 * time.Minute
+)
+
+var (
+	quotaMu         sync.Mutex
+	quotaStates     []QuotaStatus
+	quotaOnce       sync.Once
+	quotaEvents     = make(chan []QuotaStatus, 16)
+	quotaList       []TrafficQuota
+	quotaThresholds []float64
+	// 只读模式（如 --dry-run）下已触发的阈值只记录在内存中，不写入状态文件，避免占用正式运行时的告警与动作
+	dryRunAlerts      = map[string]float64{}
+	dryRunAlertPeriod time.Time
+)
+
+// StartTrafficQuota 根据 --traffic-quota 启动后台检查，未配置时不做任何事
+func StartTrafficQuota() {
+	quotaOnce.Do(func() {
+		quotas, err := parseTrafficQuotas(flags.TrafficQuota)
+		if err != nil {
+			log.Println("Invalid --traffic-quota:", err)
+			return
+		}
+		if len(quotas) == 0 {
+			return
+		}
+		thresholds, err := parseQuotaThresholds(flags.TrafficQuotaWarn)
+		if err != nil {
+			log.Println("Invalid --traffic-quota-warn:", err)
+			return
+		}
+		// 用尽（100%）总是作为一个阈值，配额动作在此时执行
+		if crossedThreshold(100, thresholds) != 100 {
+			thresholds = append(thresholds, 100)
+			sort.Float64s(thresholds)
+		}
+		quotaList, quotaThresholds = quotas, thresholds
+		pollTrafficQuota()
+		go func() {
+			ticker := time.NewTicker(quotaPollInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				pollTrafficQuota()
+			}
+		}()
+	})
+}
+
+// TrafficQuotaStatus 返回最近一次检查的全部配额状态
+func TrafficQuotaStatus() []QuotaStatus {
+	quotaMu.Lock()
+	defer quotaMu.Unlock()
+	return quotaStates
+}
+
+// TrafficQuotaEvents 配额用量越过告警阈值时推送对应的配额状态
+func TrafficQuotaEvents() <-chan []QuotaStatus {
+	return quotaEvents
+}
+
+// parseTrafficQuotas 解析 [网卡|total:]方向:大小，多条以分号分隔，省略网卡时为 total
+func parseTrafficQuotas(spec string) ([]TrafficQuota, error) {
+	var quotas []TrafficQuota
+	for _, item := range strings.Split(spec, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		q := TrafficQuota{Interface: "total"}
+		switch len(parts) {
+		case 2:
+		case 3:
+			q.Interface = strings.TrimSpace(parts[0])
+			parts = parts[1:]
+		default:
+			return nil, fmt.Errorf("%q: expected [interface|total:]direction:size", item)
+		}
+		q.Direction = strings.ToLower(strings.TrimSpace(parts[0]))
+		switch q.Direction {
+		case "in", "out", "sum", "max":
+		default:
+			return nil, fmt.Errorf("%q: direction must be in, out, sum or max", item)
+		}
+		limit, err := parseByteSize(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", item, err)
+		}
+		if limit == 0 {
+			return nil, fmt.Errorf("%q: size must be greater than 0", item)
+		}
+		q.Limit = limit
+		quotas = append(quotas, q)
+	}
+	return quotas, nil
+}
+
+var byteUnits = map[string]uint64{
+	"":    1,
+	"B":   1,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+}
+
+// parseByteSize 解析 500GB、1.5TiB 这样的大小：KB/MB/GB/TB 按 1000 进制，KiB/MiB/GiB/TiB 按 1024 进制
+func parseByteSize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	unit, ok := byteUnits[strings.ToUpper(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit in %q", s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return uint64(n * float64(unit)), nil
+}
+
+// parseQuotaThresholds 解析逗号分隔的告警百分比，升序返回
+func parseQuotaThresholds(spec string) ([]float64, error) {
+	var thresholds []float64
+	for _, v := range strings.Split(spec, ",") {
+		v = strings.TrimSuffix(strings.TrimSpace(v), "%")
+		if v == "" {
+			continue
+		}
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t <= 0 {
+			return nil, fmt.Errorf("invalid threshold %q", v)
+		}
+		thresholds = append(thresholds, t)
+	}
+	sort.Float64s(thresholds)
+	return thresholds, nil
+}
+
+func pollTrafficQuota() {
+	// 合计配额遵循 --include-nics / --exclude-nics，同时借此更新流量统计状态
+	up, down, err := PeriodTraffic(parseNics(flags.IncludeNics), parseNics(flags.ExcludeNics))
+	if err != nil {
+		log.Println("Traffic quota check failed:", err)
+		return
+	}
+	byInterface := periodTrafficByInterface()
+	now := time.Now()
+	start := billingPeriodStart(now, trafficResetDay())
+	end := billingPeriodEnd(now, trafficResetDay())
+
+	states := make([]QuotaStatus, 0, len(quotaList))
+	var alerts []QuotaStatus
+	for _, q := range quotaList {
+		out, in := up, down
+		if q.Interface != "total" {
+			v := byInterface[q.Interface]
+			out, in = v[0], v[1]
+		}
+		st := quotaStatus(q, in, out, start, end, now)
+		if t := crossedThreshold(st.Percent, quotaThresholds); t > 0 {
+			if prev, raised := raiseQuotaAlert(st.Name, t); raised {
+				alert := st
+				alert.Threshold = t
+				alerts = append(alerts, alert)
+				log.Printf("Traffic quota %s reached %g%% threshold: %d of %d bytes used, projected %d bytes by %s",
+					st.Name, t, st.Used, st.Limit, st.Projected, end.Format("2006-01-02"))
+				if st.Exceeded && prev < 100 && flags.TrafficQuotaAction != "" {
+					if flags.DryRun {
+						log.Printf("Dry run: skipping traffic quota action for %s", st.Name)
+					} else {
+						go runQuotaAction(st)
+					}
+				}
+			}
+		}
+		states = append(states, st)
+	}
+
+	quotaMu.Lock()
+	quotaStates = states
+	quotaMu.Unlock()
+
+	if len(alerts) > 0 {
+		select {
+		case quotaEvents <- alerts:
+		default:
+			// 通道已满时丢弃，周期上报仍会携带最新状态
+		}
+	}
+}
+
+// quotaStatus 按配额方向计算用量，并按本周期已过去的时间线性推算周期末用量
+func quotaStatus(q TrafficQuota, in, out uint64, start, end, now time.Time) QuotaStatus {
+	st := QuotaStatus{
+		Name:        q.Name(),
+		Interface:   q.Interface,
+		Direction:   q.Direction,
+		Limit:       q.Limit,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}
+	switch q.Direction {
+	case "in":
+		st.Used = in
+	case "out":
+		st.Used = out
+	case "sum":
+		st.Used = in + out
+	case "max":
+		st.Used = max(in, out)
+	}
+	st.Percent = float64(st.Used) / float64(q.Limit) * 100
+	st.Exceeded = st.Used >= q.Limit
+	st.Projected = st.Used
+	if elapsed := now.Sub(start); elapsed > 0 && now.Before(end) {
+		st.Projected = uint64(float64(st.Used) / elapsed.Seconds() * end.Sub(start).Seconds())
+	}
+	return st
+}
+
+// crossedThreshold 返回已达到的最高告警阈值，未达到任何阈值时返回 0
+func crossedThreshold(percent float64, thresholds []float64) float64 {
+	crossed := 0.0
+	for _, t := range thresholds {
+		if percent >= t {
+			crossed = t
+		}
+	}
+	return crossed
+}
+
+// raiseQuotaAlert 记录配额已触发的阈值，返回此前记录的阈值以及本次是否更高
+// 记录保存在流量统计状态中，agent 重启后同一周期内不会重复告警或重复执行动作
+func raiseQuotaAlert(name string, threshold float64) (prev float64, raised bool) {
+	trafficMu.Lock()
+	defer trafficMu.Unlock()
+	if traffic == nil {
+		return 0, false
+	}
+	prev = traffic.QuotaAlerts[name]
+	if trafficReadOnly {
+		if !dryRunAlertPeriod.Equal(traffic.PeriodStart) {
+			dryRunAlerts, dryRunAlertPeriod = map[string]float64{}, traffic.PeriodStart
+		}
+		prev = max(prev, dryRunAlerts[name])
+		if threshold <= prev {
+			return prev, false
+		}
+		dryRunAlerts[name] = threshold
+		return prev, true
+	}
+	if threshold <= prev {
+		return prev, false
+	}
+	if traffic.QuotaAlerts == nil {
+		traffic.QuotaAlerts = map[string]float64{}
+	}
+	traffic.QuotaAlerts[name] = threshold
+	if err := saveTrafficState(TrafficStatePath(), traffic); err != nil {
+		log.Println("Failed to save traffic state:", err)
+	}
+	trafficSavedAt = time.Now()
+	return prev, true
+}
+
+// runQuotaAction 配额用尽时执行 --traffic-quota-action，配额信息通过环境变量传入
+func runQuotaAction(st QuotaStatus) {
+	ctx, cancel := context.WithTimeout(context.Background(), quotaActionTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-Command", flags.TrafficQuotaAction)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", flags.TrafficQuotaAction)
+	}
+	cmd.Env = append(os.Environ(),
+		"KOMARI_QUOTA_NAME="+st.Name,
+		"KOMARI_QUOTA_INTERFACE="+st.Interface,
+		"KOMARI_QUOTA_DIRECTION="+st.Direction,
+		"KOMARI_QUOTA_USED="+strconv.FormatUint(st.Used, 10),
+		"KOMARI_QUOTA_LIMIT="+strconv.FormatUint(st.Limit, 10),
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Printf("Traffic quota action for %s failed: %v: %s", st.Name, err, strings.TrimSpace(string(out)))
+		return
+	}
+	log.Printf("Traffic quota action for %s finished", st.Name)
+}
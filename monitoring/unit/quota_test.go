@@ -0,0 +1,171 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint64
+	}{
+		{"1024", 1024},
+		{"500GB", 500e9},
+		{"1TB", 1e12},
+		{"1.5 TiB", 3 << 39},
+		{"200gib", 200 << 30},
+		{"10MiB", 10 << 20},
+	}
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.input)
+		if err != nil || got != tt.expected {
+			t.Errorf("parseByteSize(%q) = %d, %v, want %d", tt.input, got, err, tt.expected)
+		}
+	}
+	for _, input := range []string{"", "GB", "10PB", "1.2.3GB"} {
+		if _, err := parseByteSize(input); err == nil {
+			t.Errorf("parseByteSize(%q) should fail", input)
+		}
+	}
+}
+
+func TestParseTrafficQuotas(t *testing.T) {
+	quotas, err := parseTrafficQuotas(" sum:1TB; eth0:out:500GiB ;total:max:2TB;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []TrafficQuota{
+		{Interface: "total", Direction: "sum", Limit: 1e12},
+		{Interface: "eth0", Direction: "out", Limit: 500 << 30},
+		{Interface: "total", Direction: "max", Limit: 2e12},
+	}
+	if len(quotas) != len(expected) {
+		t.Fatalf("quotas = %+v", quotas)
+	}
+	for i := range expected {
+		if quotas[i] != expected[i] {
+			t.Errorf("quota %d = %+v, want %+v", i, quotas[i], expected[i])
+		}
+	}
+	if quotas[1].Name() != "eth0:out" {
+		t.Errorf("name = %s", quotas[1].Name())
+	}
+	for _, spec := range []string{"eth0:both:1TB", "1TB", "sum:0", "a:b:in:1TB"} {
+		if _, err := parseTrafficQuotas(spec); err == nil {
+			t.Errorf("parseTrafficQuotas(%q) should fail", spec)
+		}
+	}
+}
+
+func TestQuotaStatus(t *testing.T) {
+	start := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(10 * 24 * time.Hour) // 30 天周期已过去 10 天
+
+	st := quotaStatus(TrafficQuota{Interface: "total", Direction: "sum", Limit: 1000}, 300, 100, start, end, now)
+	if st.Used != 400 || st.Percent != 40 || st.Exceeded {
+		t.Errorf("sum status = %+v", st)
+	}
+	if st.Projected != 1200 {
+		t.Errorf("projected = %d, want 1200", st.Projected)
+	}
+
+	st = quotaStatus(TrafficQuota{Interface: "eth0", Direction: "max", Limit: 300}, 300, 100, start, end, now)
+	if st.Used != 300 || !st.Exceeded {
+		t.Errorf("max status = %+v", st)
+	}
+	st = quotaStatus(TrafficQuota{Interface: "eth0", Direction: "out", Limit: 300}, 300, 100, start, end, now)
+	if st.Used != 100 {
+		t.Errorf("out status = %+v", st)
+	}
+}
+
+func TestQuotaThresholds(t *testing.T) {
+	thresholds, err := parseQuotaThresholds("90, 50%,100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thresholds) != 3 || thresholds[0] != 50 || thresholds[2] != 100 {
+		t.Fatalf("thresholds = %v", thresholds)
+	}
+	if _, err := parseQuotaThresholds("80,abc"); err == nil {
+		t.Error("invalid threshold should fail")
+	}
+
+	tests := map[float64]float64{10: 0, 50: 50, 95.5: 90, 100: 100, 130: 100}
+	for percent, expected := range tests {
+		if got := crossedThreshold(percent, thresholds); got != expected {
+			t.Errorf("crossedThreshold(%v) = %v, want %v", percent, got, expected)
+		}
+	}
+}
+
+func TestRaiseQuotaAlert(t *testing.T) {
+	trafficMu.Lock()
+	saved := traffic
+	traffic = &trafficState{Interfaces: map[string]*trafficCounter{}}
+	trafficMu.Unlock()
+	defer func() {
+		trafficMu.Lock()
+		traffic = saved
+		trafficMu.Unlock()
+	}()
+	oldPath := flags.TrafficStateFile
+	flags.TrafficStateFile = filepath.Join(t.TempDir(), "traffic.json")
+	defer func() { flags.TrafficStateFile = oldPath }()
+
+	if prev, raised := raiseQuotaAlert("total:sum", 80); !raised || prev != 0 {
+		t.Errorf("first alert = %v, %v", prev, raised)
+	}
+	if _, raised := raiseQuotaAlert("total:sum", 80); raised {
+		t.Error("same threshold should not alert twice")
+	}
+	if prev, raised := raiseQuotaAlert("total:sum", 100); !raised || prev != 80 {
+		t.Errorf("higher alert = %v, %v", prev, raised)
+	}
+
+	// 状态文件中保留已触发的阈值，重启后不会重复告警
+	reloaded := loadTrafficState(TrafficStatePath())
+	if reloaded.QuotaAlerts["total:sum"] != 100 {
+		t.Errorf("persisted alerts = %v", reloaded.QuotaAlerts)
+	}
+}
+
+func TestRaiseQuotaAlertDryRun(t *testing.T) {
+	trafficMu.Lock()
+	saved := traffic
+	savedReadOnly := trafficReadOnly
+	traffic = &trafficState{Interfaces: map[string]*trafficCounter{}, QuotaAlerts: map[string]float64{"total:sum": 80}}
+	trafficReadOnly = true
+	trafficMu.Unlock()
+	oldPath := flags.TrafficStateFile
+	flags.TrafficStateFile = filepath.Join(t.TempDir(), "traffic.json")
+	defer func() {
+		trafficMu.Lock()
+		traffic, trafficReadOnly = saved, savedReadOnly
+		trafficMu.Unlock()
+		flags.TrafficStateFile = oldPath
+	}()
+
+	if _, raised := raiseQuotaAlert("total:sum", 80); raised {
+		t.Error("threshold already recorded by the daemon should not alert")
+	}
+	if prev, raised := raiseQuotaAlert("total:sum", 100); !raised || prev != 80 {
+		t.Errorf("dry run alert = %v, %v", prev, raised)
+	}
+	if _, raised := raiseQuotaAlert("total:sum", 100); raised {
+		t.Error("dry run should not alert twice")
+	}
+	// 试运行不修改也不写入状态文件
+	if traffic.QuotaAlerts["total:sum"] != 80 {
+		t.Errorf("state alerts = %v", traffic.QuotaAlerts)
+	}
+	if _, err := os.Stat(flags.TrafficStateFile); !os.IsNotExist(err) {
+		t.Errorf("state file should not be written: %v", err)
+	}
+}
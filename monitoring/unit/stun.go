@@ -0,0 +1,129 @@
+package monitoring
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/dnsresolver"
+)
+
+// RFC 5389 绑定请求所需的常量
+const (
+	stunBindingRequest  = 0x0001
+	stunBindingSuccess  = 0x0101
+	stunMagicCookie     = 0x2112A442
+	stunMappedAddress   = 0x0001
+	stunXorMappedAddr   = 0x0020
+	stunHeaderLength    = 20
+	stunRequestTimeout  = 5 * time.Second
+	stunFamilyIPv4      = 0x01
+	stunFamilyIPv6      = 0x02
+	stunMaxResponseSize = 1500
+)
+
+// stunIP 向 STUN 服务器发送绑定请求，返回服务器看到的源地址；network 为 udp4 或 udp6
+func stunIP(network, server string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), stunRequestTimeout)
+	defer cancel()
+	conn, err := dnsresolver.GetNetDialer(stunRequestTimeout).DialContext(ctx, network, server)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(stunRequestTimeout))
+
+	req := make([]byte, stunHeaderLength)
+	binary.BigEndian.PutUint16(req[0:2], stunBindingRequest)
+	binary.BigEndian.PutUint32(req[4:8], stunMagicCookie)
+	if _, err := rand.Read(req[8:20]); err != nil {
+		return "", err
+	}
+	if _, err := conn.Write(req); err != nil {
+		return "", err
+	}
+	resp := make([]byte, stunMaxResponseSize)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return "", err
+	}
+	ip, err := parseStunResponse(resp[:n], req[8:20])
+	if err != nil {
+		return "", err
+	}
+	return ip.String(), nil
+}
+
+// parseStunResponse 从绑定成功响应中取出映射地址，优先使用 XOR-MAPPED-ADDRESS
+func parseStunResponse(resp, transactionID []byte) (net.IP, error) {
+	if len(resp) < stunHeaderLength {
+		return nil, errors.New("stun response too short")
+	}
+	if binary.BigEndian.Uint16(resp[0:2]) != stunBindingSuccess {
+		return nil, fmt.Errorf("unexpected stun message type 0x%04x", binary.BigEndian.Uint16(resp[0:2]))
+	}
+	if binary.BigEndian.Uint32(resp[4:8]) != stunMagicCookie || string(resp[8:20]) != string(transactionID) {
+		return nil, errors.New("stun response does not match request")
+	}
+	length := int(binary.BigEndian.Uint16(resp[2:4]))
+	if stunHeaderLength+length > len(resp) {
+		return nil, errors.New("stun response truncated")
+	}
+
+	var mapped net.IP
+	attrs := resp[stunHeaderLength : stunHeaderLength+length]
+	for len(attrs) >= 4 {
+		typ := binary.BigEndian.Uint16(attrs[0:2])
+		size := int(binary.BigEndian.Uint16(attrs[2:4]))
+		if 4+size > len(attrs) {
+			break
+		}
+		value := attrs[4 : 4+size]
+		switch typ {
+		case stunXorMappedAddr:
+			if ip := stunAddress(value, resp[4:20]); ip != nil {
+				return ip, nil
+			}
+		case stunMappedAddress:
+			mapped = stunAddress(value, nil)
+		}
+		// 属性按 4 字节对齐
+		attrs = attrs[min(len(attrs), 4+(size+3)&^3):]
+	}
+	if mapped != nil {
+		return mapped, nil
+	}
+	return nil, errors.New("no mapped address in stun response")
+}
+
+// stunAddress 解析地址属性；xorKey 为魔数与事务 ID，非空时按 XOR-MAPPED-ADDRESS 还原
+func stunAddress(value, xorKey []byte) net.IP {
+	if len(value) < 4 {
+		return nil
+	}
+	var ip net.IP
+	switch value[1] {
+	case stunFamilyIPv4:
+		if len(value) < 8 {
+			return nil
+		}
+		ip = net.IP(append([]byte(nil), value[4:8]...))
+	case stunFamilyIPv6:
+		if len(value) < 20 {
+			return nil
+		}
+		ip = net.IP(append([]byte(nil), value[4:20]...))
+	default:
+		return nil
+	}
+	if xorKey != nil {
+		for i := range ip {
+			ip[i] ^= xorKey[i]
+		}
+	}
+	return ip
+}
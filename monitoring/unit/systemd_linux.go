@@ -0,0 +1,59 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// systemctl 调用开销较大，失败单元列表按此间隔缓存
+const failedUnitsCacheTTL = time.Minute
+
+var (
+	failedUnitsMu    sync.Mutex
+	failedUnitsCache []string
+	failedUnitsOK    bool
+	failedUnitsAt    time.Time
+)
+
+// FailedUnits 返回处于 failed 状态的 systemd 单元，ok 为 false 表示系统未使用 systemd
+func FailedUnits() (units []string, ok bool) {
+	// 与 sd_booted() 判断方式一致
+	if st, err := os.Stat(hostPath("run/systemd/system")); err != nil || !st.IsDir() {
+		return nil, false
+	}
+
+	failedUnitsMu.Lock()
+	defer failedUnitsMu.Unlock()
+	if !failedUnitsAt.IsZero() && time.Since(failedUnitsAt) < failedUnitsCacheTTL {
+		return failedUnitsCache, failedUnitsOK
+	}
+
+	// systemctl 不可用（如容器内未挂载）时同样缓存，避免每次上报都重新执行
+	out, err := exec.Command("systemctl", "list-units", "--state=failed", "--no-legend", "--plain", "--no-pager").Output()
+	if err != nil {
+		failedUnitsCache, failedUnitsOK = nil, false
+	} else {
+		failedUnitsCache, failedUnitsOK = parseFailedUnits(string(out)), true
+	}
+	failedUnitsAt = time.Now()
+	return failedUnitsCache, failedUnitsOK
+}
+
+func parseFailedUnits(output string) []string {
+	units := []string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(strings.TrimLeft(scanner.Text(), "● *"))
+		if len(fields) > 0 {
+			units = append(units, fields[0])
+		}
+	}
+	return units
+}
@@ -0,0 +1,22 @@
+//go:build linux
+// +build linux
+
+package monitoring
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFailedUnits(t *testing.T) {
+	output := "nginx.service loaded failed failed A high performance web server\n" +
+		"● backup.timer  loaded failed failed Nightly backup\n\n"
+	got := parseFailedUnits(output)
+	want := []string{"nginx.service", "backup.timer"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseFailedUnits = %v, want %v", got, want)
+	}
+	if got := parseFailedUnits(""); got == nil || len(got) != 0 {
+		t.Errorf("expected empty non-nil slice, got %#v", got)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package monitoring
+
+// FailedUnits 非 Linux 平台没有 systemd
+func FailedUnits() (units []string, ok bool) {
+	return nil, false
+}
@@ -0,0 +1,337 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// trafficState 持久化的流量统计状态，按网卡累计当前计费周期内的字节数
+type trafficState struct {
+	PeriodStart time.Time                  `json:"period_start"`
+	BootTime    uint64                     `json:"boot_time"`
+	Interfaces  map[string]*trafficCounter `json:"interfaces"`
+	// 本周期内各流量配额已触发的最高告警阈值（百分比），进入新周期时清空
+	QuotaAlerts map[string]float64 `json:"quota_alerts,omitempty"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// trafficCounter 单个网卡的原始计数器快照与周期累计值
+type trafficCounter struct {
+	MAC    string `json:"mac,omitempty"`
+	LastRx uint64 `json:"last_rx"`
+	LastTx uint64 `json:"last_tx"`
+	Rx     uint64 `json:"rx"`
+	Tx     uint64 `json:"tx"`
+}
+
+const (
+	trafficSaveInterval = time.Minute
+	// 启动时间在此范围内的抖动不视为重启
+	trafficBootTolerance = 2
+)
+
+var (
+	trafficMu      sync.Mutex
+	traffic        *trafficState
+	trafficSavedAt time.Time
+	// 只读模式下状态只在内存中累计，不写回状态文件，见 SetTrafficReadOnly
+	trafficReadOnly bool
+)
+
+// SetTrafficReadOnly 让本进程只读取流量统计状态文件而不写回，
+// 用于 --dry-run 与本地诊断命令，避免与正在运行的 agent 争用同一文件或推进配额告警记录
+func SetTrafficReadOnly() {
+	trafficMu.Lock()
+	defer trafficMu.Unlock()
+	trafficReadOnly = true
+}
+
+// TrafficStatePath 返回流量统计状态文件路径，默认位于 agent 可执行文件所在目录
+func TrafficStatePath() string {
+	if flags.TrafficStateFile != "" {
+		return flags.TrafficStateFile
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "komari-traffic.json"
+	}
+	return filepath.Join(filepath.Dir(exe), "komari-traffic.json")
+}
+
+// trafficResetDay 计费周期重置日，未设置 --month-rotate 时按自然月统计
+func trafficResetDay() int {
+	if flags.MonthRotate > 0 {
+		return flags.MonthRotate
+	}
+	return 1
+}
+
+// resetDate 返回指定月份的重置日；重置日超过当月天数时取当月最后一天
+func resetDate(year int, month time.Month, resetDay int, loc *time.Location) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	last := first.AddDate(0, 1, -1).Day()
+	day := resetDay
+	if day > last {
+		day = last
+	}
+	if day < 1 {
+		day = 1
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, loc)
+}
+
+// billingPeriodStart 返回 now 所在计费周期的开始时间
+func billingPeriodStart(now time.Time, resetDay int) time.Time {
+	start := resetDate(now.Year(), now.Month(), resetDay, now.Location())
+	if now.Before(start) {
+		start = resetDate(now.Year(), now.Month()-1, resetDay, now.Location())
+	}
+	return start
+}
+
+// billingPeriodEnd 返回 now 所在计费周期的结束时间（即下一周期的开始）
+func billingPeriodEnd(now time.Time, resetDay int) time.Time {
+	start := billingPeriodStart(now, resetDay)
+	return resetDate(start.Year(), start.Month()+1, resetDay, now.Location())
+}
+
+// counterDelta 计算两次原始计数之间的增量，计数回退视为计数器被重置（如 ppp/wg 重连、网卡重建）；
+// 只有明确来自 32 位计数器（wrap32）时才按回绕补齐
+func counterDelta(last, cur uint64, wrap32 bool) uint64 {
+	if cur >= last {
+		return cur - last
+	}
+	if wrap32 && last <= math.MaxUint32 && last-cur > math.MaxUint32/2 {
+		return math.MaxUint32 - last + cur + 1
+	}
+	return cur
+}
+
+// PeriodTraffic 返回当前计费周期内所有被统计网卡的上传/下载字节数，并更新持久化状态
+func PeriodTraffic(includeNics, excludeNics map[string]struct{}) (up, down uint64, err error) {
+	counters, err := net.IOCounters(true)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get network IO counters: %w", err)
+	}
+	bootTime, _ := host.BootTime()
+
+	trafficMu.Lock()
+	defer trafficMu.Unlock()
+	if traffic == nil {
+		traffic = loadTrafficState(TrafficStatePath())
+	}
+	now := time.Now()
+	track := trafficTracked(parseNics(flags.IncludeNics), parseNics(flags.ExcludeNics))
+	rolled := traffic.update(counters, bootTime, now, trafficResetDay(), interfaceMACs, track)
+	if !trafficReadOnly && (rolled || time.Since(trafficSavedAt) >= trafficSaveInterval) {
+		if err := saveTrafficState(TrafficStatePath(), traffic); err != nil {
+			log.Println("Failed to save traffic state:", err)
+		}
+		trafficSavedAt = time.Now()
+	}
+
+	for name, c := range traffic.Interfaces {
+		if shouldInclude(name, includeNics, excludeNics) {
+			up += c.Tx
+			down += c.Rx
+		}
+	}
+	return up, down, nil
+}
+
+// trafficTracked 只记录被统计的网卡以及单独配置了配额的网卡，
+// 容器 veth、cni 等随 Pod 频繁增减的虚拟网卡不写入状态文件
+func trafficTracked(includeNics, excludeNics map[string]struct{}) func(string) bool {
+	quotaNics := map[string]bool{}
+	for _, q := range quotaList {
+		quotaNics[q.Interface] = true
+	}
+	return func(name string) bool {
+		return quotaNics[name] || shouldInclude(name, includeNics, excludeNics)
+	}
+}
+
+// periodTrafficByInterface 返回状态中每个网卡的周期累计值（上传、下载）
+func periodTrafficByInterface() map[string][2]uint64 {
+	trafficMu.Lock()
+	defer trafficMu.Unlock()
+	result := map[string][2]uint64{}
+	if traffic == nil {
+		return result
+	}
+	for name, c := range traffic.Interfaces {
+		result[name] = [2]uint64{c.Tx, c.Rx}
+	}
+	return result
+}
+
+// update 合并一次计数器采样，只保留 track 接受的网卡，返回是否进入了新的计费周期
+func (s *trafficState) update(counters []net.IOCountersStat, bootTime uint64, now time.Time, resetDay int, macs func() map[string]string, track func(string) bool) bool {
+	rolled := false
+	if start := billingPeriodStart(now, resetDay); !s.PeriodStart.Equal(start) {
+		rolled = !s.PeriodStart.IsZero()
+		s.PeriodStart = start
+		s.QuotaAlerts = nil
+		present := map[string]bool{}
+		for _, c := range counters {
+			present[c.Name] = true
+		}
+		for name, c := range s.Interfaces {
+			if !present[name] {
+				delete(s.Interfaces, name) // 已消失的网卡在新周期中不再保留
+				continue
+			}
+			c.Rx, c.Tx = 0, 0
+		}
+	}
+
+	rebooted := s.BootTime != 0 && bootTime != 0 &&
+		(bootTime > s.BootTime+trafficBootTolerance || bootTime+trafficBootTolerance < s.BootTime)
+	if bootTime != 0 {
+		s.BootTime = bootTime
+	}
+
+	// 筛选条件变化或旧版本写入的未统计网卡直接移除
+	for name := range s.Interfaces {
+		if !track(name) {
+			delete(s.Interfaces, name)
+		}
+	}
+
+	var macByName map[string]string
+	seen := map[string]bool{}
+	for _, c := range counters {
+		seen[c.Name] = true
+	}
+	for _, c := range counters {
+		if !track(c.Name) {
+			continue
+		}
+		counter, ok := s.Interfaces[c.Name]
+		fresh := false
+		if !ok {
+			if macByName == nil {
+				macByName = macs()
+			}
+			mac := macByName[c.Name]
+			counter = s.adoptRenamed(mac, seen)
+			if counter == nil {
+				// 首次出现的网卡从当前计数开始统计，之前的流量不属于本 agent 的观测范围
+				counter = &trafficCounter{MAC: mac}
+				fresh = true
+			}
+			s.Interfaces[c.Name] = counter
+			if counter.MAC == "" {
+				counter.MAC = mac
+			}
+		}
+		switch {
+		case fresh:
+		case rebooted:
+			// 重启后计数器从 0 开始，当前值即为重启以来的流量
+			counter.Rx += c.BytesRecv
+			counter.Tx += c.BytesSent
+		default:
+			// gopsutil 在各平台读取的都是 64 位计数器，不会在 2^32 处回绕
+			counter.Rx += counterDelta(counter.LastRx, c.BytesRecv, false)
+			counter.Tx += counterDelta(counter.LastTx, c.BytesSent, false)
+		}
+		counter.LastRx, counter.LastTx = c.BytesRecv, c.BytesSent
+	}
+	s.UpdatedAt = now
+	return rolled
+}
+
+// adoptRenamed 查找 MAC 相同且原名称已不存在的网卡，视为被重命名并从旧名称下移出
+func (s *trafficState) adoptRenamed(mac string, present map[string]bool) *trafficCounter {
+	if mac == "" {
+		return nil
+	}
+	for name, c := range s.Interfaces {
+		if c.MAC == mac && !present[name] {
+			delete(s.Interfaces, name)
+			return c
+		}
+	}
+	return nil
+}
+
+func interfaceMACs() map[string]string {
+	macs := map[string]string{}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return macs
+	}
+	for _, iface := range ifaces {
+		macs[iface.Name] = iface.HardwareAddr
+	}
+	return macs
+}
+
+func loadTrafficState(path string) *trafficState {
+	s := &trafficState{Interfaces: map[string]*trafficCounter{}}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, s); err != nil {
+			log.Printf("Failed to parse traffic state %s, starting over: %v", path, err)
+			s = &trafficState{Interfaces: map[string]*trafficCounter{}}
+		}
+		if s.Interfaces == nil {
+			s.Interfaces = map[string]*trafficCounter{}
+		}
+		return s
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Failed to read traffic state %s: %v", path, err)
+	}
+	importVnstat(s)
+	return s
+}
+
+// importVnstat 首次建立状态时，若安装了 vnstat 则导入其当前计费周期的流量
+func importVnstat(s *trafficState) {
+	vnstatData, err := getVnstatData()
+	if err != nil {
+		return
+	}
+	counters, err := net.IOCounters(true)
+	if err != nil {
+		return
+	}
+	raw := map[string]net.IOCountersStat{}
+	for _, c := range counters {
+		raw[c.Name] = c
+	}
+	macs := interfaceMACs()
+	for name, iface := range vnstatData {
+		rx, tx := calculateMonthlyUsage(iface, trafficResetDay())
+		c := raw[name]
+		s.Interfaces[name] = &trafficCounter{MAC: macs[name], LastRx: c.BytesRecv, LastTx: c.BytesSent, Rx: rx, Tx: tx}
+	}
+	s.PeriodStart = billingPeriodStart(time.Now(), trafficResetDay())
+	log.Printf("Imported traffic for %d interfaces from vnstat", len(vnstatData))
+}
+
+// saveTrafficState 先写临时文件再重命名，避免写入中断时损坏状态
+func saveTrafficState(path string, s *trafficState) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,159 @@
+package monitoring
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+func TestBillingPeriodStart(t *testing.T) {
+	loc := time.UTC
+	tests := []struct {
+		now      time.Time
+		day      int
+		expected time.Time
+	}{
+		{time.Date(2025, 3, 15, 10, 0, 0, 0, loc), 1, time.Date(2025, 3, 1, 0, 0, 0, 0, loc)},
+		{time.Date(2025, 3, 15, 10, 0, 0, 0, loc), 20, time.Date(2025, 2, 20, 0, 0, 0, 0, loc)},
+		{time.Date(2025, 1, 5, 0, 0, 0, 0, loc), 10, time.Date(2024, 12, 10, 0, 0, 0, 0, loc)},
+		// 重置日超过当月天数时取月末
+		{time.Date(2025, 2, 28, 12, 0, 0, 0, loc), 31, time.Date(2025, 2, 28, 0, 0, 0, 0, loc)},
+		{time.Date(2025, 3, 30, 12, 0, 0, 0, loc), 31, time.Date(2025, 2, 28, 0, 0, 0, 0, loc)},
+		{time.Date(2025, 3, 31, 0, 0, 0, 0, loc), 31, time.Date(2025, 3, 31, 0, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := billingPeriodStart(tt.now, tt.day); !got.Equal(tt.expected) {
+			t.Errorf("billingPeriodStart(%v, %d) = %v, want %v", tt.now, tt.day, got, tt.expected)
+		}
+	}
+}
+
+func TestCounterDelta(t *testing.T) {
+	if got := counterDelta(100, 250, false); got != 150 {
+		t.Errorf("normal delta = %d", got)
+	}
+	// 明确的 32 位计数器回绕
+	if got := counterDelta(math.MaxUint32-99, 50, true); got != 150 {
+		t.Errorf("wrap delta = %d", got)
+	}
+	// 网卡重建导致计数器归零
+	if got := counterDelta(5_000_000_000, 1000, false); got != 1000 {
+		t.Errorf("reset delta = %d", got)
+	}
+	// 64 位计数器在 2^31 到 2^32 之间被重置，不能当作回绕
+	if got := counterDelta(3_000_000_000, 1000, false); got != 1000 {
+		t.Errorf("reset below 2^32 delta = %d", got)
+	}
+}
+
+func TestTrafficStateUpdate(t *testing.T) {
+	s := &trafficState{Interfaces: map[string]*trafficCounter{}}
+	macs := func() map[string]string {
+		return map[string]string{"eth0": "52:54:00:aa:bb:cc", "ens3": "52:54:00:aa:bb:cc", "eth1": "52:54:00:11:22:33"}
+	}
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	sample := func(name string, rx, tx uint64) net.IOCountersStat {
+		return net.IOCountersStat{Name: name, BytesRecv: rx, BytesSent: tx}
+	}
+	all := func(string) bool { return true }
+
+	// 首次采样只建立基线
+	s.update([]net.IOCountersStat{sample("eth0", 1000, 500)}, 1000, day, 1, macs, all)
+	if c := s.Interfaces["eth0"]; c.Rx != 0 || c.Tx != 0 {
+		t.Fatalf("first sample should not count: %+v", c)
+	}
+
+	s.update([]net.IOCountersStat{sample("eth0", 3000, 1500)}, 1000, day.Add(time.Minute), 1, macs, all)
+	if c := s.Interfaces["eth0"]; c.Rx != 2000 || c.Tx != 1000 {
+		t.Fatalf("unexpected delta: %+v", c)
+	}
+
+	// 重启后计数器从 0 开始
+	s.update([]net.IOCountersStat{sample("eth0", 400, 100)}, 5000, day.Add(time.Hour), 1, macs, all)
+	if c := s.Interfaces["eth0"]; c.Rx != 2400 || c.Tx != 1100 {
+		t.Fatalf("unexpected total after reboot: %+v", c)
+	}
+
+	// 重命名后沿用原累计值
+	s.update([]net.IOCountersStat{sample("ens3", 600, 200)}, 5000, day.Add(2*time.Hour), 1, macs, all)
+	if _, ok := s.Interfaces["eth0"]; ok {
+		t.Fatal("old name should be removed after rename")
+	}
+	if c := s.Interfaces["ens3"]; c == nil || c.Rx != 2600 || c.Tx != 1200 {
+		t.Fatalf("unexpected total after rename: %+v", c)
+	}
+
+	// 进入新计费周期后清零
+	rolled := s.update([]net.IOCountersStat{sample("ens3", 900, 300)}, 5000, time.Date(2025, 4, 1, 0, 5, 0, 0, time.Local), 1, macs, all)
+	if !rolled {
+		t.Fatal("expected period rollover")
+	}
+	if c := s.Interfaces["ens3"]; c.Rx != 300 || c.Tx != 100 {
+		t.Fatalf("unexpected total after rollover: %+v", c)
+	}
+}
+
+func TestTrafficStateUpdateTracksIncludedOnly(t *testing.T) {
+	s := &trafficState{Interfaces: map[string]*trafficCounter{
+		"veth1a2b3c": {LastRx: 100, Rx: 50}, // 旧版本写入的容器网卡
+	}}
+	macs := func() map[string]string { return map[string]string{} }
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	track := trafficTracked(nil, nil)
+	for i, name := range []string{"veth9f8e7d", "cni0", "docker0", "lo"} {
+		s.update([]net.IOCountersStat{
+			{Name: "eth0", BytesRecv: uint64(i) * 100},
+			{Name: name, BytesRecv: 1000},
+		}, 1000, day.Add(time.Duration(i)*time.Minute), 1, macs, track)
+	}
+	if len(s.Interfaces) != 1 || s.Interfaces["eth0"] == nil {
+		t.Fatalf("expected only eth0 to be tracked, got %v", s.Interfaces)
+	}
+	if c := s.Interfaces["eth0"]; c.Rx != 300 {
+		t.Errorf("unexpected eth0 total: %+v", c)
+	}
+}
+
+func TestTrafficStateSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.json")
+	s := &trafficState{
+		PeriodStart: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		BootTime:    1234,
+		Interfaces:  map[string]*trafficCounter{"eth0": {MAC: "aa", LastRx: 10, LastTx: 20, Rx: 30, Tx: 40}},
+	}
+	if err := saveTrafficState(path, s); err != nil {
+		t.Fatalf("saveTrafficState failed: %v", err)
+	}
+	loaded := loadTrafficState(path)
+	if !loaded.PeriodStart.Equal(s.PeriodStart) || loaded.BootTime != 1234 || *loaded.Interfaces["eth0"] != *s.Interfaces["eth0"] {
+		t.Errorf("unexpected loaded state: %+v", loaded)
+	}
+}
+
+func TestPeriodTrafficReadOnly(t *testing.T) {
+	trafficMu.Lock()
+	saved, savedReadOnly, savedAt := traffic, trafficReadOnly, trafficSavedAt
+	traffic, trafficSavedAt = nil, time.Time{}
+	trafficMu.Unlock()
+	oldPath := flags.TrafficStateFile
+	flags.TrafficStateFile = filepath.Join(t.TempDir(), "traffic.json")
+	defer func() {
+		trafficMu.Lock()
+		traffic, trafficReadOnly, trafficSavedAt = saved, savedReadOnly, savedAt
+		trafficMu.Unlock()
+		flags.TrafficStateFile = oldPath
+	}()
+
+	SetTrafficReadOnly()
+	if _, _, err := PeriodTraffic(nil, nil); err != nil {
+		t.Skipf("network counters unavailable: %v", err)
+	}
+	if _, err := os.Stat(flags.TrafficStateFile); !os.IsNotExist(err) {
+		t.Errorf("read-only traffic should not write the state file: %v", err)
+	}
+}
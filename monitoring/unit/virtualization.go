@@ -11,26 +11,41 @@ import (
 )
 
 func Virtualized() string {
+	virt, _ := DetectVirtualization()
+	return virt
+}
+
+// DetectVirtualization returns the virtualization type together with the method that detected it,
+// e.g. "systemd-detect-virt", "container markers" or "cpuid".
+func DetectVirtualization() (virt, method string) {
 	// Windows: use CPUID to detect hypervisor presence and vendor.
 	if runtime.GOOS == "windows" {
-		return detectByCPUID()
+		return detectByCPUID(), "cpuid"
 	}
 
 	// Linux/others: prefer systemd-detect-virt if available; fallback to CPUID.
+	// With --host-root the agent itself runs in a container, so systemd-detect-virt would describe
+	// the container rather than the host; rely on the host's markers and CPUID instead.
+	if isHostRootSet() {
+		if ct := detectContainer(); ct != "" {
+			return ct, "container markers"
+		}
+		return detectByCPUID(), "cpuid"
+	}
 	if out, err := exec.Command("systemd-detect-virt").Output(); err == nil {
 		virt := strings.TrimSpace(string(out))
 		if virt != "" {
-			return virt
+			return virt, "systemd-detect-virt"
 		}
 	}
 
 	// Non-systemd environments (e.g., Alpine containers): try container heuristics.
 	if ct := detectContainer(); ct != "" {
-		return ct
+		return ct, "container markers"
 	}
 
 	// Fallback (any OS): CPUID hypervisor bit and vendor mapping.
-	return detectByCPUID()
+	return detectByCPUID(), "cpuid"
 }
 
 // detectByCPUID uses cpuid to check if running under a hypervisor and maps vendor to a common name.
@@ -70,10 +85,10 @@ func detectByCPUID() string {
 // Returns a systemd-detect-virt-like string such as "docker", "podman", "lxc", "container" or empty if not detected.
 func detectContainer() string {
 	// Definite file markers first.
-	if fileExists("/.dockerenv") {
+	if fileExists(hostPath("/.dockerenv")) {
 		return "docker"
 	}
-	if fileExists("/run/.containerenv") { // podman / CRI-O
+	if fileExists(hostPath("/run/.containerenv")) { // podman / CRI-O
 		if s := parseCgroupForContainer(); s != "" {
 			return s
 		}
@@ -84,7 +99,7 @@ func detectContainer() string {
 	if s := parseCgroupForContainer(); s != "" {
 		return s
 	}
-	if fileExists("/.komari-agent-container") {
+	if fileExists(hostPath("/.komari-agent-container")) {
 		return "container"
 	}
 	// (Removed mountinfo heuristics which caused host false positives when Docker/Kube tools are installed.)
@@ -99,7 +114,12 @@ func fileExists(p string) bool {
 }
 
 func parseCgroupForContainer() string {
-	data, err := os.ReadFile("/proc/self/cgroup")
+	// 监控宿主机时检查宿主机 init 进程的 cgroup，而不是 agent 所在容器
+	cgroupFile := "/proc/self/cgroup"
+	if isHostRootSet() {
+		cgroupFile = hostPath("proc/1/cgroup")
+	}
+	data, err := os.ReadFile(cgroupFile)
 	if err != nil {
 		return ""
 	}
@@ -0,0 +1,268 @@
+package monitoring
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/shirou/gopsutil/v4/host"
+)
+
+// WatchState 被监视的进程或 systemd 单元的状态
+type WatchState struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // process / unit
+	State    string `json:"state"`
+	Running  bool   `json:"running"`
+	PID      int32  `json:"pid"`
+	Count    int    `json:"count,omitempty"` // 匹配到的进程数量
+	Restarts int    `json:"restarts"`
+	Uptime   uint64 `json:"uptime"` // 秒
+	Memory   uint64 `json:"memory"` // 字节
+}
+
+// watchTarget 单个监视项
+type watchTarget struct {
+	name    string
+	unit    bool
+	pattern *regexp.Regexp
+
+	seen      bool
+	lastKey   string // pid + 启动时间，用于识别重启
+	restarts  int
+	lastState WatchState
+}
+
+const watchdogPollInterval = 5 * time.Second
+
+var (
+	watchdogMu      sync.Mutex
+	watchdogTargets []*watchTarget
+	watchdogStates  []WatchState
+	watchdogOnce    sync.Once
+	watchdogEvents  = make(chan []WatchState, 16)
+)
+
+// 命令行在进程存续期间基本不变，按 pid 与启动时间跨轮次缓存，只读取新出现的进程
+var watchdogCmdlines = map[string]string{}
+
+// StartWatchdog 根据 --watch-process / --watch-unit 启动后台监视，未配置时不做任何事
+func StartWatchdog() {
+	watchdogOnce.Do(func() {
+		targets := parseWatchTargets(flags.WatchProcesses, flags.WatchUnits)
+		if len(targets) == 0 {
+			return
+		}
+		watchdogTargets = targets
+		pollWatchdog()
+		go func() {
+			ticker := time.NewTicker(watchdogPollInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				pollWatchdog()
+			}
+		}()
+	})
+}
+
+// WatchdogStatus 返回最近一次检查的全部监视项状态
+func WatchdogStatus() []WatchState {
+	watchdogMu.Lock()
+	defer watchdogMu.Unlock()
+	return watchdogStates
+}
+
+// WatchdogEvents 状态发生变化（启动、停止、重启）时推送变化的监视项
+func WatchdogEvents() <-chan []WatchState {
+	return watchdogEvents
+}
+
+func parseWatchTargets(processes, units string) []*watchTarget {
+	var targets []*watchTarget
+	for _, expr := range strings.Split(processes, ";") {
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			log.Printf("Invalid watch process pattern %q: %v", expr, err)
+			continue
+		}
+		targets = append(targets, &watchTarget{name: expr, pattern: re})
+	}
+	for _, unit := range strings.Split(units, ",") {
+		unit = strings.TrimSpace(unit)
+		if unit == "" {
+			continue
+		}
+		targets = append(targets, &watchTarget{name: unit, unit: true})
+	}
+	return targets
+}
+
+func pollWatchdog() {
+	watchdogMu.Lock()
+	defer watchdogMu.Unlock()
+
+	var samples []processSample
+	var boot uint64
+	for _, t := range watchdogTargets {
+		if !t.unit {
+			var err error
+			if samples, err = sampleProcesses(); err != nil {
+				log.Println("Watchdog failed to list processes:", err)
+			}
+			boot, _ = host.BootTime()
+			break
+		}
+	}
+
+	states := make([]WatchState, 0, len(watchdogTargets))
+	var changed []WatchState
+	for _, t := range watchdogTargets {
+		var st WatchState
+		var key string
+		if t.unit {
+			st, key = checkUnit(t.name)
+		} else {
+			st, key = checkProcess(t.pattern, samples, watchdogCmdlines, boot)
+		}
+		st.Name = t.name
+
+		// 首次检查不计重启；此后进程标识变化或由停止转为运行都视为一次重启
+		if t.seen && st.Running && key != t.lastKey {
+			t.restarts++
+		}
+		if t.unit && st.Restarts > t.restarts {
+			t.restarts = st.Restarts
+		}
+		st.Restarts = t.restarts
+
+		if t.seen && (st.Running != t.lastState.Running || st.State != t.lastState.State || st.PID != t.lastState.PID) {
+			changed = append(changed, st)
+		}
+		t.seen = true
+		t.lastKey = key
+		t.lastState = st
+		states = append(states, st)
+	}
+	watchdogStates = states
+	pruneCmdlines(watchdogCmdlines, samples)
+
+	if len(changed) > 0 {
+		select {
+		case watchdogEvents <- changed:
+		default:
+			// 通道已满时丢弃，下一次周期上报仍会携带最新状态
+		}
+	}
+}
+
+// checkProcess 按进程名或命令行匹配，多个匹配时以最早启动的进程为主进程
+// 进程名不匹配时才读取命令行，cmdlines 为按 processSample.key 索引的命令行缓存
+func checkProcess(pattern *regexp.Regexp, samples []processSample, cmdlines map[string]string, boot uint64) (WatchState, string) {
+	st := WatchState{Type: "process", State: "stopped"}
+	self := int32(os.Getpid())
+	var main *processSample
+	for i := range samples {
+		s := &samples[i]
+		// 跳过 agent 自身，避免命令行参数中的正则匹配到自己
+		if s.pid == self {
+			continue
+		}
+		if !pattern.MatchString(s.name) {
+			cmdline, ok := cmdlines[s.key()]
+			if !ok {
+				cmdline = processCmdline(s.pid)
+				cmdlines[s.key()] = cmdline
+			}
+			if !pattern.MatchString(cmdline) {
+				continue
+			}
+		}
+		st.Count++
+		st.Memory += s.rss
+		if main == nil || s.startTime < main.startTime {
+			main = s
+		}
+	}
+	if main == nil {
+		return st, ""
+	}
+	st.State = "running"
+	st.Running = true
+	st.PID = main.pid
+	if started := processStartedAt(*main, boot); !started.IsZero() && time.Since(started) > 0 {
+		st.Uptime = uint64(time.Since(started).Seconds())
+	}
+	return st, main.key()
+}
+
+// pruneCmdlines 移除已退出进程的命令行缓存
+func pruneCmdlines(cmdlines map[string]string, samples []processSample) {
+	alive := make(map[string]bool, len(samples))
+	for _, s := range samples {
+		alive[s.key()] = true
+	}
+	for k := range cmdlines {
+		if !alive[k] {
+			delete(cmdlines, k)
+		}
+	}
+}
+
+// checkUnit 通过 systemctl show 读取单元状态
+func checkUnit(unit string) (WatchState, string) {
+	st := WatchState{Type: "unit", State: "unknown"}
+	out, err := exec.Command("systemctl", "show", unit,
+		"-p", "ActiveState", "-p", "SubState", "-p", "MainPID", "-p", "NRestarts",
+		"-p", "MemoryCurrent", "-p", "ActiveEnterTimestampMonotonic").Output()
+	if err != nil {
+		return st, ""
+	}
+	props := parseSystemctlShow(string(out))
+	if props["ActiveState"] != "" {
+		st.State = props["ActiveState"]
+		if sub := props["SubState"]; sub != "" {
+			st.State += "/" + sub
+		}
+	}
+	st.Running = props["ActiveState"] == "active"
+	if pid, err := strconv.ParseInt(props["MainPID"], 10, 32); err == nil {
+		st.PID = int32(pid)
+	}
+	if n, err := strconv.Atoi(props["NRestarts"]); err == nil {
+		st.Restarts = n
+	}
+	if mem, err := strconv.ParseUint(props["MemoryCurrent"], 10, 64); err == nil {
+		st.Memory = mem // 未启用内存统计时为 [not set]，解析失败即保持 0
+	}
+	if st.Running {
+		if enter, err := strconv.ParseUint(props["ActiveEnterTimestampMonotonic"], 10, 64); err == nil && enter > 0 {
+			if uptime, err := host.Uptime(); err == nil && uptime > enter/1e6 {
+				st.Uptime = uptime - enter/1e6
+			}
+		}
+		return st, props["MainPID"] + ":" + props["ActiveEnterTimestampMonotonic"]
+	}
+	return st, ""
+}
+
+func parseSystemctlShow(output string) map[string]string {
+	props := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if k, v, ok := strings.Cut(scanner.Text(), "="); ok {
+			props[k] = strings.TrimSpace(v)
+		}
+	}
+	return props
+}
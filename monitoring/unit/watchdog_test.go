@@ -0,0 +1,54 @@
+package monitoring
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestParseWatchTargets(t *testing.T) {
+	targets := parseWatchTargets("nginx; ^redis-server$ ;[invalid", "sshd.service, ,docker.service")
+	if len(targets) != 4 {
+		t.Fatalf("expected 4 targets, got %d", len(targets))
+	}
+	if targets[0].name != "nginx" || targets[0].unit {
+		t.Errorf("unexpected first target: %+v", targets[0])
+	}
+	if !targets[3].unit || targets[3].name != "docker.service" {
+		t.Errorf("unexpected last target: %+v", targets[3])
+	}
+}
+
+func TestCheckProcess(t *testing.T) {
+	samples := []processSample{
+		{pid: 10, name: "nginx", rss: 100, startTime: 50},
+		{pid: 11, name: "nginx", rss: 200, startTime: 20},
+		{pid: 12, name: "bash", rss: 300, startTime: 10},
+	}
+	st, key := checkProcess(regexp.MustCompile(`^nginx$`), samples, map[string]string{}, 0)
+	if !st.Running || st.PID != 11 || st.Count != 2 || st.Memory != 300 {
+		t.Errorf("unexpected state: %+v", st)
+	}
+	if key != "11:20" {
+		t.Errorf("key = %q, want 11:20", key)
+	}
+
+	st, key = checkProcess(regexp.MustCompile(`^redis`), samples, map[string]string{"12:10": "bash"}, 0)
+	if st.Running || st.State != "stopped" || key != "" {
+		t.Errorf("expected stopped state, got %+v (%q)", st, key)
+	}
+}
+
+func TestPruneCmdlines(t *testing.T) {
+	cmdlines := map[string]string{"10:50": "nginx: master", "12:10": "bash", "13:99": "exited"}
+	pruneCmdlines(cmdlines, []processSample{{pid: 10, startTime: 50}, {pid: 12, startTime: 11}})
+	if len(cmdlines) != 1 || cmdlines["10:50"] != "nginx: master" {
+		t.Errorf("unexpected cache: %v", cmdlines)
+	}
+}
+
+func TestParseSystemctlShow(t *testing.T) {
+	props := parseSystemctlShow("ActiveState=active\nSubState=running\nMainPID=123\nMemoryCurrent=[not set]\n")
+	if props["ActiveState"] != "active" || props["MainPID"] != "123" || props["MemoryCurrent"] != "[not set]" {
+		t.Errorf("unexpected props: %v", props)
+	}
+}
@@ -4,13 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/komari-monitor/komari-agent/cmd/flags"
 	"github.com/komari-monitor/komari-agent/dnsresolver"
+	"github.com/komari-monitor/komari-agent/logger"
 	monitoring "github.com/komari-monitor/komari-agent/monitoring/unit"
 	"github.com/komari-monitor/komari-agent/update"
 )
@@ -20,19 +20,36 @@ func DoUploadBasicInfoWorks() {
 	for range ticker.C {
 		err := uploadBasicInfo()
 		if err != nil {
-			log.Println("Error uploading basic info:", err)
+			logger.Errorf(nil, "Error uploading basic info: %v", err)
 		}
 	}
 }
 func UpdateBasicInfo() {
 	err := uploadBasicInfo()
 	if err != nil {
-		log.Println("Error uploading basic info:", err)
+		logger.Errorf(nil, "Error uploading basic info: %v", err)
 	} else {
-		log.Println("Basic info uploaded successfully")
+		logger.Printf(nil, "Basic info uploaded successfully")
 	}
 }
 func uploadBasicInfo() error {
+	data := BasicInfo()
+
+	// 尝试上传完整数据
+	err := tryUploadData(data)
+	if err != nil {
+		// 兼容 <= 1.0.2
+		delete(data, "kernel_version")
+		err = tryUploadData(data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BasicInfo 采集上报给服务端的基本信息
+func BasicInfo() map[string]interface{} {
 	cpu := monitoring.Cpu()
 
 	osname := monitoring.OSName()
@@ -52,20 +69,34 @@ func uploadBasicInfo() error {
 		"disk_total":     monitoring.Disk().Total,
 		"gpu_name":       monitoring.GpuName(),
 		"virtualization": monitoring.Virtualized(),
+		"icmp_mode":      ICMPMode(),
 		"version":        update.CurrentVersion,
 	}
 
-	// 尝试上传完整数据
-	err := tryUploadData(data)
-	if err != nil {
-		// 兼容 <= 1.0.2
-		delete(data, "kernel_version")
-		err = tryUploadData(data)
+	if flags.Kubernetes {
+		node, err := monitoring.KubernetesNodeInfo()
 		if err != nil {
-			return err
+			logger.Errorf(nil, "Failed to get Kubernetes node info: %v", err)
+		}
+		if node != nil {
+			data["kubernetes"] = node
 		}
 	}
-	return nil
+
+	if interfaces, err := monitoring.NetInterfaces(); err != nil {
+		logger.Errorf(nil, "Failed to list network interfaces: %v", err)
+	} else {
+		data["interfaces"] = interfaces
+	}
+
+	if flags.EnableDiskHealth {
+		data["disk_health"] = monitoring.DiskHealthStatus()
+	}
+
+	if flags.EnableListeners {
+		data["listeners"] = monitoring.ListenerStatus()
+	}
+	return data
 }
 
 func tryUploadData(data map[string]interface{}) error {
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/komari-monitor/komari-agent/logger"
+	"github.com/komari-monitor/komari-agent/monitoring"
+	monitoringUnit "github.com/komari-monitor/komari-agent/monitoring/unit"
+)
+
+// RunDryRun 按正常周期采集基本信息和监控数据，只写入日志而不连接服务端
+func RunDryRun() {
+	logger.Printf(nil, "Dry run: payloads are logged instead of being sent")
+	logPayload("basic info", BasicInfo())
+
+	infoTicker := time.NewTicker(time.Duration(flags.InfoReportInterval) * time.Minute)
+	defer infoTicker.Stop()
+	dataTicker := time.NewTicker(ReportInterval())
+	defer dataTicker.Stop()
+	watchdogEvents := monitoringUnit.WatchdogEvents()
+	diskHealthEvents := monitoringUnit.DiskHealthEvents()
+	quotaEvents := monitoringUnit.TrafficQuotaEvents()
+	interfaceEvents := monitoringUnit.NetInterfaceEvents()
+	listenerEvents := monitoringUnit.ListenerEvents()
+
+	for {
+		select {
+		case <-infoTicker.C:
+			logPayload("basic info", BasicInfo())
+		case <-dataTicker.C:
+			logger.Printf(nil, "Dry run report: %s", monitoring.GenerateReport())
+		case changes := <-watchdogEvents:
+			logPayload("watchdog event", map[string]interface{}{"type": "watchdog_event", "changes": changes, "time": time.Now()})
+		case changes := <-diskHealthEvents:
+			logPayload("disk health event", map[string]interface{}{"type": "disk_health_event", "changes": changes, "time": time.Now()})
+		case changes := <-quotaEvents:
+			logPayload("traffic quota event", map[string]interface{}{"type": "traffic_quota_event", "changes": changes, "time": time.Now()})
+		case changes := <-interfaceEvents:
+			logPayload("interface event", map[string]interface{}{"type": "interface_event", "changes": changes, "time": time.Now()})
+		case changes := <-listenerEvents:
+			logPayload("listener event", map[string]interface{}{"type": "listener_event", "changes": changes, "time": time.Now()})
+		}
+	}
+}
+
+func logPayload(kind string, v interface{}) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		logger.Errorf(nil, "Dry run: failed to marshal %s: %v", kind, err)
+		return
+	}
+	logger.Printf(nil, "Dry run %s: %s", kind, payload)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/komari-monitor/komari-agent/logger"
+	"golang.org/x/net/icmp"
+)
+
+const (
+	// ICMPModePrivileged 使用 raw socket，需要 root 或 CAP_NET_RAW
+	ICMPModePrivileged = "privileged"
+	// ICMPModeUnprivileged 使用 Linux/macOS 的 datagram ICMP socket（受 ping_group_range 限制）
+	ICMPModeUnprivileged = "unprivileged"
+	// ICMPModeUnavailable 两种方式均不可用，ICMP 探测将直接失败
+	ICMPModeUnavailable = "unavailable"
+)
+
+var (
+	icmpModeOnce sync.Once
+	icmpMode     string
+)
+
+// ICMPMode 返回当前可用的 ICMP 模式，首次调用时检测并缓存
+func ICMPMode() string {
+	icmpModeOnce.Do(func() {
+		icmpMode = detectICMPMode()
+		if icmpMode == ICMPModeUnavailable {
+			logger.Printf(nil, "ICMP mode: %s (raw socket denied; ping_group_range: %s)", icmpMode, pingGroupRange())
+		} else {
+			logger.Printf(nil, "ICMP mode: %s", icmpMode)
+		}
+	})
+	return icmpMode
+}
+
+func detectICMPMode() string {
+	// Windows 下 pro-bing 只支持特权模式，且普通用户即可使用
+	if runtime.GOOS == "windows" {
+		return ICMPModePrivileged
+	}
+	if c, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0"); err == nil {
+		c.Close()
+		return ICMPModePrivileged
+	}
+	if c, err := icmp.ListenPacket("udp4", "0.0.0.0"); err == nil {
+		c.Close()
+		return ICMPModeUnprivileged
+	}
+	return ICMPModeUnavailable
+}
+
+// pingGroupRange 读取允许使用 datagram ICMP socket 的 GID 范围，仅用于诊断
+func pingGroupRange() string {
+	data, err := os.ReadFile("/proc/sys/net/ipv4/ping_group_range")
+	if err != nil {
+		return "unknown"
+	}
+	return strings.Join(strings.Fields(string(data)), "-")
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+)
+
+// probeOptions 探测的源地址与地址族选项，用于多出口主机分别监测各条线路
+type probeOptions struct {
+	// Source 源网卡名称或源 IP，为空则由路由表决定
+	Source string
+	// Family 地址族："ip"（不限）、"ip4" 或 "ip6"
+	Family string
+}
+
+// newProbeOptions 合并任务参数与全局默认设置，任务参数优先
+func newProbeOptions(source, family string) (probeOptions, error) {
+	if source == "" {
+		source = flags.PingSource
+	}
+	if family == "" {
+		family = flags.PingFamily
+	}
+	network, err := parseAddressFamily(family)
+	if err != nil {
+		return probeOptions{}, err
+	}
+	return probeOptions{Source: strings.TrimSpace(source), Family: network}, nil
+}
+
+// parseAddressFamily 将 "4"/"ipv4"/"6"/"ipv6" 等写法规范化为 net 包的网络名
+func parseAddressFamily(family string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(family)) {
+	case "", "any", "ip":
+		return "ip", nil
+	case "4", "v4", "ipv4", "ip4":
+		return "ip4", nil
+	case "6", "v6", "ipv6", "ip6":
+		return "ip6", nil
+	default:
+		return "", fmt.Errorf("invalid address family: %s", family)
+	}
+}
+
+// resolveIPFamily 按地址族解析目标地址，排除 DNS 查询时间
+func resolveIPFamily(target, network string) (string, error) {
+	if ip := net.ParseIP(target); ip != nil {
+		if !familyMatches(ip, network) {
+			return "", fmt.Errorf("target %s does not match address family %s", target, network)
+		}
+		return target, nil
+	}
+	ips, err := net.DefaultResolver.LookupIP(context.Background(), network, target)
+	if err != nil || len(ips) == 0 {
+		return "", errors.New("failed to resolve target")
+	}
+	return ips[0].String(), nil
+}
+
+func familyMatches(ip net.IP, network string) bool {
+	switch network {
+	case "ip4":
+		return ip.To4() != nil
+	case "ip6":
+		return ip.To4() == nil
+	default:
+		return true
+	}
+}
+
+// isInterfaceSource 判断 Source 是否为网卡名称而非 IP
+func (o probeOptions) isInterfaceSource() bool {
+	return o.Source != "" && net.ParseIP(o.Source) == nil
+}
+
+// sourceIP 返回与目标地址同族的源 IP；Source 为空时返回 nil
+func (o probeOptions) sourceIP(target net.IP) (net.IP, error) {
+	if o.Source == "" {
+		return nil, nil
+	}
+	wantV6 := target.To4() == nil
+	if ip := net.ParseIP(o.Source); ip != nil {
+		if (ip.To4() == nil) != wantV6 {
+			return nil, fmt.Errorf("source %s does not match target %s address family", o.Source, target)
+		}
+		return ip, nil
+	}
+
+	iface, err := net.InterfaceByName(o.Source)
+	if err != nil {
+		return nil, fmt.Errorf("source interface %s: %w", o.Source, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("source interface %s: %w", o.Source, err)
+	}
+	var linkLocal net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || (ipNet.IP.To4() == nil) != wantV6 {
+			continue
+		}
+		if ipNet.IP.IsLinkLocalUnicast() {
+			if linkLocal == nil {
+				linkLocal = ipNet.IP
+			}
+			continue
+		}
+		return ipNet.IP, nil
+	}
+	if linkLocal != nil {
+		return linkLocal, nil
+	}
+	return nil, fmt.Errorf("source interface %s has no usable address for %s", o.Source, target)
+}
+
+// dialer 构造绑定源地址的拨号器
+func (o probeOptions) dialer(target net.IP, network string) (*net.Dialer, error) {
+	d := &net.Dialer{}
+	src, err := o.sourceIP(target)
+	if err != nil {
+		return nil, err
+	}
+	if src == nil {
+		return d, nil
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		d.LocalAddr = &net.TCPAddr{IP: src}
+	case "udp", "udp4", "udp6":
+		d.LocalAddr = &net.UDPAddr{IP: src}
+	}
+	// 仅绑定源地址时，没有策略路由的多出口主机仍会从默认路由发出，需要同时绑定网卡
+	if o.isInterfaceSource() {
+		d.Control = bindToDevice(o.Source)
+	}
+	return d, nil
+}
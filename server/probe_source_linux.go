@@ -0,0 +1,18 @@
+//go:build linux
+
+package server
+
+import "syscall"
+
+// bindToDevice 通过 SO_BINDTODEVICE 将套接字绑定到网卡，流量从该网卡发出而不受默认路由影响
+func bindToDevice(iface string) func(network, address string, c syscall.RawConn) error {
+	return func(_, _ string, c syscall.RawConn) error {
+		var serr error
+		if err := c.Control(func(fd uintptr) {
+			serr = syscall.BindToDevice(int(fd), iface)
+		}); err != nil {
+			return err
+		}
+		return serr
+	}
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package server
+
+import (
+	"net"
+	"testing"
+)
+
+func TestProbeOptionsDialerBindsDevice(t *testing.T) {
+	d, err := probeOptions{Source: "lo"}.dialer(net.ParseIP("127.0.0.1"), "tcp")
+	if err != nil {
+		t.Skipf("loopback interface unavailable: %v", err)
+	}
+	if d.Control == nil {
+		t.Fatal("interface source should bind the socket to the device")
+	}
+	if addr, ok := d.LocalAddr.(*net.TCPAddr); !ok || !addr.IP.IsLoopback() {
+		t.Errorf("unexpected local address: %v", d.LocalAddr)
+	}
+
+	d, err = probeOptions{Source: "127.0.0.1"}.dialer(net.ParseIP("127.0.0.1"), "tcp")
+	if err != nil || d.Control != nil {
+		t.Errorf("IP source should not bind to a device: %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package server
+
+import "syscall"
+
+// bindToDevice 仅 Linux 支持 SO_BINDTODEVICE，其它平台只绑定源地址
+func bindToDevice(iface string) func(network, address string, c syscall.RawConn) error {
+	return nil
+}
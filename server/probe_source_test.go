@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseAddressFamily(t *testing.T) {
+	tests := map[string]string{
+		"":     "ip",
+		"4":    "ip4",
+		"IPv4": "ip4",
+		"6":    "ip6",
+		"ipv6": "ip6",
+	}
+	for input, want := range tests {
+		got, err := parseAddressFamily(input)
+		if err != nil || got != want {
+			t.Errorf("parseAddressFamily(%q) = %q, %v; want %q", input, got, err, want)
+		}
+	}
+	if _, err := parseAddressFamily("ipx"); err == nil {
+		t.Error("expected error for invalid family")
+	}
+}
+
+func TestProbeOptionsSourceIP(t *testing.T) {
+	opts := probeOptions{Source: "127.0.0.1", Family: "ip4"}
+	src, err := opts.sourceIP(net.ParseIP("127.0.0.2"))
+	if err != nil || !src.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("sourceIP = %v, %v; want 127.0.0.1", src, err)
+	}
+	if _, err := opts.sourceIP(net.ParseIP("::1")); err == nil {
+		t.Error("expected family mismatch error")
+	}
+	if _, err := resolveIPFamily("::1", "ip4"); err == nil {
+		t.Error("expected resolveIPFamily to reject IPv6 literal for ip4")
+	}
+}
@@ -0,0 +1,426 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/komari-monitor/komari-agent/dnsresolver"
+	"github.com/komari-monitor/komari-agent/logger"
+)
+
+const (
+	speedtestDefaultDuration = 10 * time.Second
+	speedtestMaxDuration     = 60 * time.Second
+	speedtestStreams         = 4
+	speedtestChunkSize       = 32 * 1024
+	speedtestLatencyInterval = 250 * time.Millisecond
+)
+
+// SpeedtestResult 测速结果，速率单位 Mbps，延迟单位毫秒
+type SpeedtestResult struct {
+	DownloadMbps     float64 `json:"download_mbps"`
+	UploadMbps       float64 `json:"upload_mbps"`
+	IdleLatency      float64 `json:"idle_latency_ms"`
+	DownloadLatency  float64 `json:"download_latency_ms"`
+	UploadLatency    float64 `json:"upload_latency_ms"`
+	BytesDownloaded  int64   `json:"bytes_downloaded"`
+	BytesUploaded    int64   `json:"bytes_uploaded"`
+	DownloadSeconds  float64 `json:"download_seconds"`
+	UploadSeconds    float64 `json:"upload_seconds"`
+	ByteLimitReached bool    `json:"byte_limit_reached"`
+}
+
+// speedtestConfig 单次测速的参数
+type speedtestConfig struct {
+	downloadURL string
+	uploadURL   string
+	duration    time.Duration // 每个方向的最长测试时间
+	maxBytes    int64         // 上下行合计的最大传输量，<=0 表示不限制
+}
+
+// NewSpeedtestTask 执行带宽测速并通过 uploadTaskResult 上传结果
+func NewSpeedtestTask(taskID, downloadURL, uploadURL string, durationSeconds int, maxBytes int64) {
+	if taskID == "" {
+		return
+	}
+	cfg := speedtestConfig{
+		downloadURL: downloadURL,
+		uploadURL:   uploadURL,
+		duration:    time.Duration(durationSeconds) * time.Second,
+		maxBytes:    maxBytes,
+	}
+	if cfg.downloadURL == "" {
+		cfg.downloadURL = flags.SpeedtestDownloadURL
+	}
+	if cfg.uploadURL == "" {
+		cfg.uploadURL = flags.SpeedtestUploadURL
+	}
+	if cfg.maxBytes <= 0 || (flags.SpeedtestMaxBytes > 0 && cfg.maxBytes > flags.SpeedtestMaxBytes) {
+		cfg.maxBytes = flags.SpeedtestMaxBytes
+	}
+	logger.Printf(logger.Fields{"task_id": taskID}, "Executing speedtest task %s: download=%s upload=%s", taskID, cfg.downloadURL, cfg.uploadURL)
+
+	result, err := runSpeedtest(cfg)
+	finishedAt := time.Now()
+	if err != nil {
+		logger.Errorf(logger.Fields{"task_id": taskID}, "Speedtest task %s failed: %v", taskID, err)
+		uploadTaskResult(taskID, err.Error(), -1, finishedAt)
+		return
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		uploadTaskResult(taskID, err.Error(), -1, finishedAt)
+		return
+	}
+	uploadTaskResult(taskID, string(data), 0, finishedAt)
+}
+
+func runSpeedtest(cfg speedtestConfig) (*SpeedtestResult, error) {
+	if cfg.downloadURL == "" && cfg.uploadURL == "" {
+		return nil, errors.New("no speedtest endpoint configured")
+	}
+	if cfg.duration <= 0 {
+		cfg.duration = speedtestDefaultDuration
+	}
+	if cfg.duration > speedtestMaxDuration {
+		cfg.duration = speedtestMaxDuration
+	}
+
+	downloadURL, err := resolveSpeedtestURL(cfg.downloadURL)
+	if err != nil {
+		return nil, err
+	}
+	uploadURL, err := resolveSpeedtestURL(cfg.uploadURL)
+	if err != nil {
+		return nil, err
+	}
+
+	client := dnsresolver.GetHTTPClient(cfg.duration + 15*time.Second)
+	budget := &byteBudget{limit: cfg.maxBytes}
+	// 下行最多占用一半配额，保证上行仍可测试
+	downloadBudget := &byteBudget{limit: cfg.maxBytes}
+	if cfg.maxBytes > 0 && uploadURL != "" {
+		downloadBudget.limit = cfg.maxBytes / 2
+	}
+	result := &SpeedtestResult{}
+
+	latencyHost := speedtestLatencyHost(downloadURL)
+	if latencyHost == "" {
+		latencyHost = speedtestLatencyHost(uploadURL)
+	}
+	if latencyHost != "" {
+		if rtt, err := tcpConnectLatency(latencyHost); err == nil {
+			result.IdleLatency = msFloat(rtt)
+		}
+	}
+
+	if downloadURL != "" {
+		n, elapsed, latency, err := speedtestPhase(cfg.duration, latencyHost, func(ctx context.Context, counter *int64) error {
+			return speedtestDownload(ctx, client, downloadURL, downloadBudget, counter)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("download test failed: %w", err)
+		}
+		budget.add(n)
+		result.BytesDownloaded = n
+		result.DownloadSeconds = elapsed.Seconds()
+		result.DownloadMbps = mbps(n, elapsed)
+		result.DownloadLatency = latency
+	}
+
+	if uploadURL != "" {
+		n, elapsed, latency, err := speedtestPhase(cfg.duration, latencyHost, func(ctx context.Context, counter *int64) error {
+			return speedtestUpload(ctx, client, uploadURL, budget, counter)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("upload test failed: %w", err)
+		}
+		result.BytesUploaded = n
+		result.UploadSeconds = elapsed.Seconds()
+		result.UploadMbps = mbps(n, elapsed)
+		result.UploadLatency = latency
+	}
+
+	result.ByteLimitReached = downloadBudget.exhausted() || budget.exhausted()
+	return result, nil
+}
+
+// speedtestPhase 并发运行多个传输流，同时测量负载下的延迟，返回传输字节数、耗时和平均延迟
+func speedtestPhase(duration time.Duration, latencyHost string, stream func(ctx context.Context, counter *int64) error) (int64, time.Duration, float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	var total int64
+
+	var wg sync.WaitGroup
+	errs := make(chan error, speedtestStreams)
+	start := time.Now()
+	for i := 0; i < speedtestStreams; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := stream(ctx, &total); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	latencyDone := make(chan float64, 1)
+	go func() {
+		latencyDone <- measureLoadedLatency(ctx, latencyHost)
+	}()
+
+	wg.Wait()
+	elapsed := time.Since(start)
+	cancel()
+	latency := <-latencyDone
+	close(errs)
+
+	n := atomic.LoadInt64(&total)
+	if n == 0 {
+		for err := range errs {
+			return 0, elapsed, latency, err
+		}
+		return 0, elapsed, latency, errors.New("no data transferred")
+	}
+	return n, elapsed, latency, nil
+}
+
+func speedtestDownload(ctx context.Context, client *http.Client, target string, budget *byteBudget, counter *int64) error {
+	buf := make([]byte, speedtestChunkSize)
+	for ctx.Err() == nil && !budget.exhausted() {
+		req, err := newSpeedtestRequest(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+		for {
+			n, err := resp.Body.Read(buf)
+			if n > 0 {
+				atomic.AddInt64(counter, int64(n))
+				if !budget.reserve(int64(n)) {
+					break
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func speedtestUpload(ctx context.Context, client *http.Client, target string, budget *byteBudget, counter *int64) error {
+	for ctx.Err() == nil && !budget.exhausted() {
+		body := &speedtestReader{ctx: ctx, budget: budget, counter: counter}
+		req, err := newSpeedtestRequest(ctx, http.MethodPost, target, body)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil || budget.exhausted() {
+				return nil
+			}
+			return err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+	}
+	return nil
+}
+
+// speedtestReader 上传数据源，在超时或配额耗尽时结束
+type speedtestReader struct {
+	ctx     context.Context
+	budget  *byteBudget
+	counter *int64
+}
+
+var speedtestPayload = func() []byte {
+	b := make([]byte, speedtestChunkSize)
+	// 简单的伪随机填充，避免链路压缩影响结果
+	x := uint32(2463534242)
+	for i := range b {
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+		b[i] = byte(x)
+	}
+	return b
+}()
+
+func (r *speedtestReader) Read(p []byte) (int, error) {
+	if r.ctx.Err() != nil {
+		return 0, io.EOF
+	}
+	n := len(p)
+	if n > len(speedtestPayload) {
+		n = len(speedtestPayload)
+	}
+	if !r.budget.reserve(int64(n)) {
+		return 0, io.EOF
+	}
+	copy(p, speedtestPayload[:n])
+	atomic.AddInt64(r.counter, int64(n))
+	return n, nil
+}
+
+// byteBudget 线程安全的传输配额，limit<=0 表示不限制
+type byteBudget struct {
+	limit int64
+	used  int64
+}
+
+// reserve 占用 n 字节配额，配额已用尽时返回 false
+func (b *byteBudget) reserve(n int64) bool {
+	used := atomic.AddInt64(&b.used, n)
+	if b.limit <= 0 {
+		return true
+	}
+	return used <= b.limit
+}
+
+func (b *byteBudget) add(n int64) {
+	atomic.AddInt64(&b.used, n)
+}
+
+func (b *byteBudget) exhausted() bool {
+	return b.limit > 0 && atomic.LoadInt64(&b.used) >= b.limit
+}
+
+// resolveSpeedtestURL 以 "/" 开头的地址视为 Komari 服务端上的路径
+func resolveSpeedtestURL(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	if strings.HasPrefix(raw, "/") {
+		if flags.Endpoint == "" {
+			return "", errors.New("relative speedtest url requires an endpoint")
+		}
+		raw = strings.TrimSuffix(flags.Endpoint, "/") + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid speedtest url: %s", raw)
+	}
+	return u.String(), nil
+}
+
+func newSpeedtestRequest(ctx context.Context, method, target string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	// 请求 Komari 服务端时附带 token 与 Cloudflare Access 头部
+	if flags.Endpoint != "" && strings.HasPrefix(target, strings.TrimSuffix(flags.Endpoint, "/")+"/") {
+		q := req.URL.Query()
+		q.Set("token", flags.Token)
+		req.URL.RawQuery = q.Encode()
+		if flags.CFAccessClientID != "" && flags.CFAccessClientSecret != "" {
+			req.Header.Set("CF-Access-Client-Id", flags.CFAccessClientID)
+			req.Header.Set("CF-Access-Client-Secret", flags.CFAccessClientSecret)
+		}
+	}
+	return req, nil
+}
+
+// speedtestLatencyHost 返回用于测量延迟的 host:port
+func speedtestLatencyHost(target string) string {
+	if target == "" {
+		return ""
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+func tcpConnectLatency(hostport string) (time.Duration, error) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return 0, err
+	}
+	ip, err := resolveIP(host)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, port), 3*time.Second)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	conn.Close()
+	return rtt, nil
+}
+
+// measureLoadedLatency 在传输期间周期性测量 TCP 建连延迟，返回平均值
+func measureLoadedLatency(ctx context.Context, hostport string) float64 {
+	if hostport == "" {
+		return 0
+	}
+	ticker := time.NewTicker(speedtestLatencyInterval)
+	defer ticker.Stop()
+	var sum time.Duration
+	count := 0
+	for {
+		select {
+		case <-ctx.Done():
+			if count == 0 {
+				return 0
+			}
+			return msFloat(sum / time.Duration(count))
+		case <-ticker.C:
+			if rtt, err := tcpConnectLatency(hostport); err == nil {
+				sum += rtt
+				count++
+			}
+		}
+	}
+}
+
+func mbps(n int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(n) * 8 / elapsed.Seconds() / 1e6
+}
+
+func msFloat(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
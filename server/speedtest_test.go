@@ -0,0 +1,55 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunSpeedtestLocal(t *testing.T) {
+	chunk := make([]byte, 64*1024)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 64; i++ {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	const maxBytes = 8 * 1024 * 1024
+	result, err := runSpeedtest(speedtestConfig{
+		downloadURL: srv.URL + "/download",
+		uploadURL:   srv.URL + "/upload",
+		duration:    time.Second,
+		maxBytes:    maxBytes,
+	})
+	if err != nil {
+		t.Fatalf("runSpeedtest failed: %v", err)
+	}
+	if result.BytesDownloaded == 0 || result.BytesUploaded == 0 {
+		t.Fatalf("expected data in both directions, got %+v", result)
+	}
+	// 允许每个并发流超出一个读取块
+	slack := int64(speedtestStreams * speedtestChunkSize)
+	if total := result.BytesDownloaded + result.BytesUploaded; total > maxBytes+slack {
+		t.Errorf("transferred %d bytes, exceeds cap %d", total, maxBytes)
+	}
+	if result.DownloadMbps <= 0 || result.UploadMbps <= 0 {
+		t.Errorf("expected positive throughput, got %+v", result)
+	}
+	t.Logf("speedtest result: %+v", result)
+}
+
+func TestRunSpeedtestNoEndpoint(t *testing.T) {
+	if _, err := runSpeedtest(speedtestConfig{}); err == nil {
+		t.Error("expected error without endpoint")
+	}
+}
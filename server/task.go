@@ -5,15 +5,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
 	"net"
 	"net/http"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/komari-monitor/komari-agent/cmd/flags"
+	"github.com/komari-monitor/komari-agent/logger"
 	"github.com/komari-monitor/komari-agent/ws"
 	ping "github.com/prometheus-community/pro-bing"
 )
@@ -30,7 +31,7 @@ func NewTask(task_id, command string) {
 		uploadTaskResult(task_id, "Remote control is disabled.", -1, time.Now())
 		return
 	}
-	log.Printf("Executing task %s with command: %s", task_id, command)
+	logger.Printf(logger.Fields{"task_id": task_id}, "Executing task %s with command: %s", task_id, command)
 	var cmd *exec.Cmd
 	if runtime.GOOS == "windows" {
 		cmd = exec.Command("powershell", "-NoProfile", "-ExecutionPolicy", "Bypass", "-Command", "[Console]::OutputEncoding = [System.Text.Encoding]::UTF8; "+command)
@@ -73,7 +74,7 @@ func uploadTaskResult(taskID, result string, exitCode int, finishedAt time.Time)
 	// 创建HTTP请求以支持自定义头部
 	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
-		log.Printf("Failed to create task result request: %v", err)
+		logger.Errorf(logger.Fields{"task_id": taskID}, "Failed to create task result request: %v", err)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
@@ -88,14 +89,14 @@ func uploadTaskResult(taskID, result string, exitCode int, finishedAt time.Time)
 	resp, err := client.Do(req)
 	maxRetry := flags.MaxRetries
 	for i := 0; i < maxRetry && (err != nil || resp.StatusCode != http.StatusOK); i++ {
-		log.Printf("Failed to upload task result, retrying %d/%d", i+1, maxRetry)
+		logger.Errorf(logger.Fields{"task_id": taskID}, "Failed to upload task result, retrying %d/%d", i+1, maxRetry)
 		time.Sleep(2 * time.Second) // Wait before retrying
 		resp, err = client.Do(req)
 	}
 	if resp != nil {
 		defer resp.Body.Close()
 		if resp.StatusCode != http.StatusOK {
-			log.Printf("Failed to upload task result: %s", resp.Status)
+			logger.Errorf(logger.Fields{"task_id": taskID}, "Failed to upload task result: %s", resp.Status)
 		}
 	}
 }
@@ -114,7 +115,7 @@ func resolveIP(target string) (string, error) {
 	return addrs[0], nil // 返回第一个解析的 IP
 }
 
-func icmpPing(target string, timeout time.Duration) (int64, error) {
+func icmpPing(target string, timeout time.Duration, opts probeOptions) (int64, error) {
 	host, _, err := net.SplitHostPort(target)
 	if err != nil {
 		host = target
@@ -124,18 +125,33 @@ func icmpPing(target string, timeout time.Duration) (int64, error) {
 	host = strings.Trim(host, "[]")
 
 	// 先解析 IP 地址
-	ip, err := resolveIP(host)
+	ip, err := resolveIPFamily(host, opts.Family)
+	if err != nil {
+		return -1, err
+	}
+	src, err := opts.sourceIP(net.ParseIP(ip))
 	if err != nil {
 		return -1, err
 	}
 
+	mode := ICMPMode()
+	if mode == ICMPModeUnavailable {
+		return -1, errors.New("ICMP is unavailable: requires root, CAP_NET_RAW or ping_group_range")
+	}
+
 	pinger, err := ping.NewPinger(ip)
 	if err != nil {
 		return -1, err
 	}
 	pinger.Count = 1
 	pinger.Timeout = timeout
-	pinger.SetPrivileged(true)
+	pinger.SetPrivileged(mode == ICMPModePrivileged)
+	if src != nil {
+		pinger.Source = src.String()
+	}
+	if opts.isInterfaceSource() {
+		pinger.InterfaceName = opts.Source
+	}
 	err = pinger.Run()
 	if err != nil {
 		return -1, err
@@ -147,7 +163,7 @@ func icmpPing(target string, timeout time.Duration) (int64, error) {
 	return stats.AvgRtt.Milliseconds(), nil
 }
 
-func tcpPing(target string, timeout time.Duration) (int64, error) {
+func tcpPing(target string, timeout time.Duration, opts probeOptions) (int64, error) {
 	host, port, err := net.SplitHostPort(target)
 	if err != nil {
 		// No port, assume port 80
@@ -155,14 +171,19 @@ func tcpPing(target string, timeout time.Duration) (int64, error) {
 		port = "80"
 	}
 
-	ip, err := resolveIP(host)
+	ip, err := resolveIPFamily(host, opts.Family)
 	if err != nil {
 		return -1, err
 	}
+	dialer, err := opts.dialer(net.ParseIP(ip), "tcp")
+	if err != nil {
+		return -1, err
+	}
+	dialer.Timeout = timeout
 
 	targetAddr := net.JoinHostPort(ip, port)
 	start := time.Now()
-	conn, err := net.DialTimeout("tcp", targetAddr, timeout)
+	conn, err := dialer.Dial("tcp", targetAddr)
 	if err != nil {
 		return -1, err
 	}
@@ -170,7 +191,7 @@ func tcpPing(target string, timeout time.Duration) (int64, error) {
 	return time.Since(start).Milliseconds(), nil
 }
 
-func httpPing(target string, timeout time.Duration) (int64, error) {
+func httpPing(target string, timeout time.Duration, opts probeOptions) (int64, error) {
 	// Handle raw IPv6 address for URL
 	if strings.Contains(target, ":") && !strings.Contains(target, "[") {
 		// check if it's a valid IP to avoid wrapping hostnames
@@ -192,11 +213,16 @@ func httpPing(target string, timeout time.Duration) (int64, error) {
 				if err != nil {
 					return nil, err
 				}
-				ip, err := resolveIP(host)
+				ip, err := resolveIPFamily(host, opts.Family)
 				if err != nil {
 					return nil, err
 				}
-				return net.DialTimeout(network, net.JoinHostPort(ip, port), timeout)
+				dialer, err := opts.dialer(net.ParseIP(ip), network)
+				if err != nil {
+					return nil, err
+				}
+				dialer.Timeout = timeout
+				return dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
 			},
 		},
 	}
@@ -213,9 +239,9 @@ func httpPing(target string, timeout time.Duration) (int64, error) {
 	return latency, errors.New("http status not ok")
 }
 
-func NewPingTask(conn *ws.SafeConn, taskID uint, pingType, pingTarget string) {
+func NewPingTask(conn *ws.SafeConn, taskID uint, pingType, pingTarget, pingSource, pingFamily string) {
 	if taskID == 0 {
-		log.Printf("Invalid task ID: %d", taskID)
+		logger.Errorf(nil, "Invalid task ID: %d", taskID)
 		return
 	}
 	var err error = nil
@@ -224,14 +250,19 @@ func NewPingTask(conn *ws.SafeConn, taskID uint, pingType, pingTarget string) {
 	timeout := 3 * time.Second        // 默认超时时间
 	const highLatencyThreshold = 1000 // ms 阈值
 
+	// 源地址/地址族参数无效时按丢包上报
+	opts, optsErr := newProbeOptions(pingSource, pingFamily)
 	measure := func() (int64, error) {
+		if optsErr != nil {
+			return -1, optsErr
+		}
 		switch pingType {
 		case "icmp":
-			return icmpPing(pingTarget, timeout)
+			return icmpPing(pingTarget, timeout, opts)
 		case "tcp":
-			return tcpPing(pingTarget, timeout)
+			return tcpPing(pingTarget, timeout, opts)
 		case "http":
-			return httpPing(pingTarget, timeout)
+			return httpPing(pingTarget, timeout, opts)
 		default:
 			return -1, errors.New("unsupported ping type")
 		}
@@ -259,7 +290,7 @@ func NewPingTask(conn *ws.SafeConn, taskID uint, pingType, pingTarget string) {
 	}
 
 	if err != nil {
-		log.Printf("Ping task %d failed: %v", taskID, err)
+		logger.Errorf(logger.Fields{"task_id": strconv.FormatUint(uint64(taskID), 10)}, "Ping task %d failed: %v", taskID, err)
 		pingResult = -1 // 如果有错误，设置结果为 -1
 	} else {
 		pingResult = int(latency)
@@ -277,7 +308,7 @@ func NewPingTask(conn *ws.SafeConn, taskID uint, pingType, pingTarget string) {
 	//	return
 	//}
 	if err := conn.WriteJSON(payload); err != nil {
-		log.Printf("Failed to write JSON to WebSocket: %v", err)
+		logger.Errorf(logger.Fields{"task_id": strconv.FormatUint(uint64(taskID), 10)}, "Failed to write JSON to WebSocket: %v", err)
 	}
 
 }
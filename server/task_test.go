@@ -20,7 +20,7 @@ func TestICMPPing(t *testing.T) {
 	timeout := 3 * time.Second
 	for _, tt := range testTargets {
 		t.Run(tt.target, func(t *testing.T) {
-			latency, err := icmpPing(tt.target, timeout)
+			latency, err := icmpPing(tt.target, timeout, probeOptions{})
 			if latency < -1 {
 				t.Errorf("ICMP ping %s: invalid latency %d", tt.target, latency)
 			}
@@ -35,7 +35,7 @@ func TestTCPPing(t *testing.T) {
 	timeout := 3 * time.Second
 	for _, tt := range testTargets {
 		t.Run(tt.target, func(t *testing.T) {
-			latency, err := tcpPing(tt.target, timeout)
+			latency, err := tcpPing(tt.target, timeout, probeOptions{})
 			if latency < -1 {
 				t.Errorf("TCP ping %s: invalid latency %d", tt.target, latency)
 			}
@@ -50,7 +50,7 @@ func TestHTTPPing(t *testing.T) {
 	timeout := 3 * time.Second
 	for _, tt := range testTargets {
 		t.Run(tt.target, func(t *testing.T) {
-			latency, err := httpPing(tt.target, timeout)
+			latency, err := httpPing(tt.target, timeout, probeOptions{})
 			if latency < -1 {
 				t.Errorf("HTTP ping %s: invalid latency %d", tt.target, latency)
 			}
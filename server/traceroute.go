@@ -0,0 +1,450 @@
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/komari-monitor/komari-agent/logger"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	tracerouteDefaultMaxHops = 30
+	tracerouteMaxHopsLimit   = 64
+	tracerouteProbesPerHop   = 3
+	tracerouteProbeTimeout   = 2 * time.Second
+	tracerouteUDPBasePort    = 33434
+)
+
+// TracerouteHop 单跳统计结果，RTT 单位为毫秒，-1 代表该探测超时
+type TracerouteHop struct {
+	TTL         int       `json:"ttl"`
+	Address     string    `json:"address"`
+	RTTs        []float64 `json:"rtts"`
+	Loss        float64   `json:"loss"`
+	Unreachable string    `json:"unreachable,omitempty"` // 中途路由返回的不可达类型，追踪在此终止
+}
+
+// TracerouteResult traceroute 任务的完整结果，以 JSON 形式上传
+type TracerouteResult struct {
+	Type    string          `json:"type"`
+	Target  string          `json:"target"`
+	IP      string          `json:"ip"`
+	Reached bool            `json:"reached"`
+	Hops    []TracerouteHop `json:"hops"`
+}
+
+// tracerouteProbe 单次探测的结果
+type tracerouteProbe struct {
+	addr        string
+	rtt         time.Duration
+	reached     bool
+	unreachable string
+}
+
+// NewTracerouteTask 执行 traceroute 并通过 uploadTaskResult 上传结果
+func NewTracerouteTask(taskID, traceType, target string, maxHops int) {
+	if taskID == "" {
+		return
+	}
+	if target == "" {
+		uploadTaskResult(taskID, "No target provided", -1, time.Now())
+		return
+	}
+	logger.Printf(logger.Fields{"task_id": taskID}, "Executing traceroute task %s: %s %s", taskID, traceType, target)
+	result, err := traceroute(traceType, target, maxHops)
+	finishedAt := time.Now()
+	if err != nil {
+		logger.Errorf(logger.Fields{"task_id": taskID}, "Traceroute task %s failed: %v", taskID, err)
+		uploadTaskResult(taskID, err.Error(), -1, finishedAt)
+		return
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		uploadTaskResult(taskID, err.Error(), -1, finishedAt)
+		return
+	}
+	exitCode := 0
+	if !result.Reached {
+		exitCode = 1
+	}
+	uploadTaskResult(taskID, string(data), exitCode, finishedAt)
+}
+
+func traceroute(traceType, target string, maxHops int) (*TracerouteResult, error) {
+	if traceType == "" {
+		traceType = "icmp"
+	}
+	if maxHops <= 0 {
+		maxHops = tracerouteDefaultMaxHops
+	}
+	if maxHops > tracerouteMaxHopsLimit {
+		maxHops = tracerouteMaxHopsLimit
+	}
+
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		host = strings.Trim(target, "[]")
+		port = ""
+	}
+	ipStr, err := resolveIP(host)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid target address: %s", ipStr)
+	}
+	isV6 := ip.To4() == nil
+
+	var dstPort int
+	switch traceType {
+	case "icmp", "udp":
+	case "tcp":
+		if port == "" {
+			port = "80"
+		}
+		dstPort, err = strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port: %s", port)
+		}
+	default:
+		return nil, errors.New("unsupported traceroute type")
+	}
+
+	// Time Exceeded 报文只能通过 raw socket 接收，datagram ICMP socket 无法用于 traceroute
+	if ICMPMode() != ICMPModePrivileged {
+		return nil, fmt.Errorf("traceroute requires privileged ICMP mode, current mode: %s", ICMPMode())
+	}
+	conn, err := listenTracerouteICMP(isV6)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ICMP socket (root or CAP_NET_RAW required): %w", err)
+	}
+	defer conn.Close()
+
+	tr := &tracer{
+		conn:    conn,
+		ip:      ip,
+		isV6:    isV6,
+		port:    dstPort,
+		id:      (os.Getpid() ^ rand.Intn(0xffff)) & 0xffff,
+		timeout: tracerouteProbeTimeout,
+	}
+
+	result := &TracerouteResult{
+		Type:   traceType,
+		Target: target,
+		IP:     ipStr,
+		Hops:   []TracerouteHop{},
+	}
+	seq := 0
+	for ttl := 1; ttl <= maxHops; ttl++ {
+		hop := TracerouteHop{TTL: ttl, RTTs: make([]float64, 0, tracerouteProbesPerHop)}
+		lost := 0
+		reached := false
+		for i := 0; i < tracerouteProbesPerHop; i++ {
+			seq++
+			var p *tracerouteProbe
+			switch traceType {
+			case "icmp":
+				p, err = tr.probeICMP(ttl, seq)
+			case "udp":
+				p, err = tr.probeUDP(ttl, seq)
+			case "tcp":
+				p, err = tr.probeTCP(ttl)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if p == nil {
+				lost++
+				hop.RTTs = append(hop.RTTs, -1)
+				continue
+			}
+			if hop.Address == "" {
+				hop.Address = p.addr
+			}
+			hop.RTTs = append(hop.RTTs, float64(p.rtt.Microseconds())/1000)
+			reached = reached || p.reached
+			if p.unreachable != "" {
+				hop.Unreachable = p.unreachable
+			}
+		}
+		hop.Loss = float64(lost) * 100 / float64(tracerouteProbesPerHop)
+		result.Hops = append(result.Hops, hop)
+		if reached {
+			result.Reached = true
+			break
+		}
+		if hop.Unreachable != "" {
+			break
+		}
+	}
+	return result, nil
+}
+
+func listenTracerouteICMP(isV6 bool) (*icmp.PacketConn, error) {
+	if isV6 {
+		return icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	}
+	return icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+}
+
+// tracer 保存一次 traceroute 过程中共享的状态
+type tracer struct {
+	conn    *icmp.PacketConn
+	ip      net.IP
+	isV6    bool
+	port    int
+	id      int
+	timeout time.Duration
+}
+
+func (t *tracer) probeICMP(ttl, seq int) (*tracerouteProbe, error) {
+	var typ icmp.Type = ipv4.ICMPTypeEcho
+	if t.isV6 {
+		typ = ipv6.ICMPTypeEchoRequest
+		if err := t.conn.IPv6PacketConn().SetHopLimit(ttl); err != nil {
+			return nil, err
+		}
+	} else if err := t.conn.IPv4PacketConn().SetTTL(ttl); err != nil {
+		return nil, err
+	}
+	msg := icmp.Message{
+		Type: typ,
+		Body: &icmp.Echo{ID: t.id, Seq: seq & 0xffff, Data: []byte("komari-traceroute")},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if _, err := t.conn.WriteTo(b, &net.IPAddr{IP: t.ip}); err != nil {
+		return nil, err
+	}
+	return t.waitICMP(start, func(proto byte, transport []byte) bool {
+		if len(transport) < 8 || (proto != 1 && proto != 58) {
+			return false
+		}
+		return int(binary.BigEndian.Uint16(transport[4:6])) == t.id &&
+			int(binary.BigEndian.Uint16(transport[6:8])) == seq&0xffff
+	}, func(echo *icmp.Echo) bool {
+		return echo.ID == t.id && echo.Seq == seq&0xffff
+	}), nil
+}
+
+func (t *tracer) probeUDP(ttl, seq int) (*tracerouteProbe, error) {
+	network, laddr := "udp4", "0.0.0.0:0"
+	if t.isV6 {
+		network, laddr = "udp6", "[::]:0"
+	}
+	uc, err := net.ListenPacket(network, laddr)
+	if err != nil {
+		return nil, err
+	}
+	defer uc.Close()
+	if t.isV6 {
+		err = ipv6.NewPacketConn(uc).SetHopLimit(ttl)
+	} else {
+		err = ipv4.NewPacketConn(uc).SetTTL(ttl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	dstPort := tracerouteUDPBasePort + seq%1000
+	start := time.Now()
+	if _, err := uc.WriteTo([]byte("komari-traceroute"), &net.UDPAddr{IP: t.ip, Port: dstPort}); err != nil {
+		return nil, err
+	}
+	return t.waitICMP(start, func(proto byte, transport []byte) bool {
+		return proto == 17 && len(transport) >= 4 && int(binary.BigEndian.Uint16(transport[2:4])) == dstPort
+	}, nil), nil
+}
+
+func (t *tracer) probeTCP(ttl int) (*tracerouteProbe, error) {
+	network := "tcp4"
+	if t.isV6 {
+		network = "tcp6"
+	}
+	// 预先取得一个空闲的本地端口并绑定，ICMP 回应中引用的源端口可据此区分各跳的探测，
+	// 避免上一跳迟到的超时报文被记到当前跳
+	localPort, err := reserveTCPPort(network)
+	if err != nil {
+		return nil, err
+	}
+	dialer := net.Dialer{
+		Timeout:   t.timeout,
+		LocalAddr: &net.TCPAddr{Port: localPort},
+		Control: func(_, _ string, c syscall.RawConn) error {
+			var serr error
+			if err := c.Control(func(fd uintptr) {
+				serr = setSocketTTL(fd, t.isV6, ttl)
+			}); err != nil {
+				return err
+			}
+			return serr
+		},
+	}
+	type dialResult struct {
+		rtt time.Duration
+		err error
+	}
+	done := make(chan dialResult, 1)
+	start := time.Now()
+	go func() {
+		c, err := dialer.Dial(network, net.JoinHostPort(t.ip.String(), strconv.Itoa(t.port)))
+		rtt := time.Since(start)
+		if c != nil {
+			c.Close()
+		}
+		done <- dialResult{rtt: rtt, err: err}
+	}()
+
+	deadline := start.Add(t.timeout)
+	match := func(proto byte, transport []byte) bool {
+		return proto == 6 && len(transport) >= 4 &&
+			int(binary.BigEndian.Uint16(transport[0:2])) == localPort &&
+			int(binary.BigEndian.Uint16(transport[2:4])) == t.port
+	}
+	for time.Now().Before(deadline) {
+		select {
+		case r := <-done:
+			// 连接成功或被目标 RST 拒绝都说明已到达目标
+			if r.err == nil || errors.Is(r.err, syscall.ECONNREFUSED) {
+				return &tracerouteProbe{addr: t.ip.String(), rtt: r.rtt, reached: true}, nil
+			}
+			// 其它错误（通常是超时）继续等待 ICMP 回应
+		default:
+		}
+		if p := t.readICMP(start, time.Now().Add(100*time.Millisecond), match, nil); p != nil {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// reserveTCPPort 由系统分配一个空闲的 TCP 端口后立即释放，供探测连接绑定
+func reserveTCPPort(network string) (int, error) {
+	l, err := net.ListenTCP(network, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitICMP 等待与探测匹配的 ICMP 回应，超时返回 nil
+func (t *tracer) waitICMP(start time.Time, matchQuoted func(proto byte, transport []byte) bool, matchEcho func(*icmp.Echo) bool) *tracerouteProbe {
+	deadline := start.Add(t.timeout)
+	for time.Now().Before(deadline) {
+		if p := t.readICMP(start, deadline, matchQuoted, matchEcho); p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// readICMP 在 deadline 前读取一个 ICMP 报文并判断是否属于当前探测
+func (t *tracer) readICMP(start, deadline time.Time, matchQuoted func(proto byte, transport []byte) bool, matchEcho func(*icmp.Echo) bool) *tracerouteProbe {
+	buf := make([]byte, 1500)
+	if err := t.conn.SetReadDeadline(deadline); err != nil {
+		return nil
+	}
+	n, peer, err := t.conn.ReadFrom(buf)
+	if err != nil {
+		return nil
+	}
+	rtt := time.Since(start)
+	proto := 1
+	if t.isV6 {
+		proto = 58
+	}
+	msg, err := icmp.ParseMessage(proto, buf[:n])
+	if err != nil {
+		return nil
+	}
+	addr := peer.String()
+	if ipAddr, ok := peer.(*net.IPAddr); ok {
+		addr = ipAddr.IP.String()
+	}
+
+	switch body := msg.Body.(type) {
+	case *icmp.Echo:
+		if matchEcho != nil && (msg.Type == ipv4.ICMPTypeEchoReply || msg.Type == ipv6.ICMPTypeEchoReply) && matchEcho(body) {
+			return &tracerouteProbe{addr: addr, rtt: rtt, reached: true}
+		}
+	case *icmp.TimeExceeded:
+		if dst, qproto, transport, ok := parseQuotedPacket(body.Data, t.isV6); ok && dst.Equal(t.ip) && matchQuoted(qproto, transport) {
+			return &tracerouteProbe{addr: addr, rtt: rtt}
+		}
+	case *icmp.DstUnreach:
+		// 目标自身回应或 UDP 探测收到端口不可达才算到达，中途路由的网络/主机不可达、管理禁止只终止追踪
+		if dst, qproto, transport, ok := parseQuotedPacket(body.Data, t.isV6); ok && dst.Equal(t.ip) && matchQuoted(qproto, transport) {
+			reason := unreachableReason(msg.Code, t.isV6)
+			if net.ParseIP(addr).Equal(t.ip) || (qproto == 17 && reason == "port") {
+				return &tracerouteProbe{addr: addr, rtt: rtt, reached: true}
+			}
+			return &tracerouteProbe{addr: addr, rtt: rtt, unreachable: reason}
+		}
+	}
+	return nil
+}
+
+// unreachableReason 将 ICMP/ICMPv6 目标不可达代码转换为可读的类型
+func unreachableReason(code int, isV6 bool) string {
+	if isV6 {
+		switch code {
+		case 0:
+			return "network"
+		case 1, 5, 6:
+			return "prohibited"
+		case 3:
+			return "host"
+		case 4:
+			return "port"
+		}
+	} else {
+		switch code {
+		case 0, 6, 11:
+			return "network"
+		case 1, 7, 12:
+			return "host"
+		case 2:
+			return "protocol"
+		case 3:
+			return "port"
+		case 9, 10, 13:
+			return "prohibited"
+		}
+	}
+	return fmt.Sprintf("code %d", code)
+}
+
+// parseQuotedPacket 解析 ICMP 差错报文中携带的原始 IP 报文，返回目标地址、上层协议号和上层报文
+func parseQuotedPacket(data []byte, isV6 bool) (net.IP, byte, []byte, bool) {
+	if isV6 {
+		if len(data) < 40 || data[0]>>4 != 6 {
+			return nil, 0, nil, false
+		}
+		return net.IP(data[24:40]), data[6], data[40:], true
+	}
+	if len(data) < 20 || data[0]>>4 != 4 {
+		return nil, 0, nil, false
+	}
+	ihl := int(data[0]&0x0f) * 4
+	if ihl < 20 || len(data) < ihl {
+		return nil, 0, nil, false
+	}
+	return net.IPv4(data[16], data[17], data[18], data[19]), data[9], data[ihl:], true
+}
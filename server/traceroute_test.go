@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseQuotedPacket(t *testing.T) {
+	// IPv4 头（IHL=5）+ UDP 头
+	v4 := make([]byte, 28)
+	v4[0] = 0x45
+	v4[9] = 17
+	copy(v4[16:20], []byte{1, 2, 3, 4})
+	v4[22], v4[23] = 0x82, 0x9a // dst port 33434
+
+	dst, proto, transport, ok := parseQuotedPacket(v4, false)
+	if !ok {
+		t.Fatal("expected IPv4 packet to parse")
+	}
+	if !dst.Equal(net.IPv4(1, 2, 3, 4)) || proto != 17 || len(transport) != 8 {
+		t.Errorf("unexpected result: dst=%v proto=%d len=%d", dst, proto, len(transport))
+	}
+
+	// IPv6 头 + ICMPv6 echo 头
+	v6 := make([]byte, 48)
+	v6[0] = 0x60
+	v6[6] = 58
+	target := net.ParseIP("2001:db8::1")
+	copy(v6[24:40], target)
+
+	dst, proto, transport, ok = parseQuotedPacket(v6, true)
+	if !ok {
+		t.Fatal("expected IPv6 packet to parse")
+	}
+	if !dst.Equal(target) || proto != 58 || len(transport) != 8 {
+		t.Errorf("unexpected result: dst=%v proto=%d len=%d", dst, proto, len(transport))
+	}
+
+	if _, _, _, ok := parseQuotedPacket(v4[:10], false); ok {
+		t.Error("expected truncated packet to be rejected")
+	}
+	if _, _, _, ok := parseQuotedPacket(v4, true); ok {
+		t.Error("expected IPv4 packet to be rejected as IPv6")
+	}
+}
+
+func TestUnreachableReason(t *testing.T) {
+	cases := []struct {
+		code int
+		isV6 bool
+		want string
+	}{
+		{0, false, "network"},
+		{1, false, "host"},
+		{3, false, "port"},
+		{13, false, "prohibited"},
+		{4, true, "port"},
+		{1, true, "prohibited"},
+		{8, false, "code 8"},
+	}
+	for _, c := range cases {
+		if got := unreachableReason(c.code, c.isV6); got != c.want {
+			t.Errorf("unreachableReason(%d, %v) = %q, want %q", c.code, c.isV6, got, c.want)
+		}
+	}
+}
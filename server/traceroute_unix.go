@@ -0,0 +1,13 @@
+//go:build !windows
+
+package server
+
+import "syscall"
+
+// setSocketTTL 设置套接字的 TTL / Hop Limit
+func setSocketTTL(fd uintptr, isV6 bool, ttl int) error {
+	if isV6 {
+		return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl)
+	}
+	return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+}
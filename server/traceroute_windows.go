@@ -0,0 +1,13 @@
+//go:build windows
+
+package server
+
+import "golang.org/x/sys/windows"
+
+// setSocketTTL 设置套接字的 TTL / Hop Limit
+func setSocketTTL(fd uintptr, isV6 bool, ttl int) error {
+	if isV6 {
+		return windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IPV6, windows.IPV6_UNICAST_HOPS, ttl)
+	}
+	return windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IP, windows.IP_TTL, ttl)
+}
@@ -4,7 +4,6 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -12,11 +11,24 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/komari-monitor/komari-agent/cmd/flags"
 	"github.com/komari-monitor/komari-agent/dnsresolver"
+	"github.com/komari-monitor/komari-agent/logger"
 	"github.com/komari-monitor/komari-agent/monitoring"
+	monitoringUnit "github.com/komari-monitor/komari-agent/monitoring/unit"
 	"github.com/komari-monitor/komari-agent/terminal"
 	"github.com/komari-monitor/komari-agent/ws"
 )
 
+// ReportInterval 上报间隔，生成报告时 CPU 采样约占 1 秒
+func ReportInterval() time.Duration {
+	var interval float64
+	if flags.Interval <= 1 {
+		interval = 1
+	} else {
+		interval = flags.Interval - 1
+	}
+	return time.Duration(interval * float64(time.Second))
+}
+
 func EstablishWebSocketConnection() {
 
 	websocketEndpoint := strings.TrimSuffix(flags.Endpoint, "/") + "/api/clients/report?token=" + flags.Token
@@ -29,43 +41,43 @@ func EstablishWebSocketConnection() {
 		}
 	}()
 	var err error
-	var interval float64
-	if flags.Interval <= 1 {
-		interval = 1
-	} else {
-		interval = flags.Interval - 1
-	}
 
-	dataTicker := time.NewTicker(time.Duration(interval * float64(time.Second)))
+	dataTicker := time.NewTicker(ReportInterval())
 	defer dataTicker.Stop()
 
 	heartbeatTicker := time.NewTicker(30 * time.Second)
 	defer heartbeatTicker.Stop()
 
+	watchdogEvents := monitoringUnit.WatchdogEvents()
+	diskHealthEvents := monitoringUnit.DiskHealthEvents()
+	quotaEvents := monitoringUnit.TrafficQuotaEvents()
+	interfaceEvents := monitoringUnit.NetInterfaceEvents()
+	listenerEvents := monitoringUnit.ListenerEvents()
+
 	for {
 		select {
 		case <-dataTicker.C:
 			if conn == nil {
-				log.Println("Attempting to connect to WebSocket...")
+				logger.Printf(nil, "Attempting to connect to WebSocket...")
 				retry := 0
 				for retry <= flags.MaxRetries {
 					if retry > 0 {
-						log.Println("Retrying websocket connection, attempt:", retry)
+						logger.Printf(nil, "Retrying websocket connection, attempt: %d", retry)
 					}
 					conn, err = connectWebSocket(websocketEndpoint)
 					if err == nil {
-						log.Println("WebSocket connected")
+						logger.Printf(nil, "WebSocket connected")
 						go handleWebSocketMessages(conn, make(chan struct{}))
 						break
 					} else {
-						log.Println("Failed to connect to WebSocket:", err)
+						logger.Errorf(nil, "Failed to connect to WebSocket: %v", err)
 					}
 					retry++
 					time.Sleep(time.Duration(flags.ReconnectInterval) * time.Second)
 				}
 
 				if retry > flags.MaxRetries {
-					log.Println("Max retries reached.")
+					logger.Errorf(nil, "Max retries reached.")
 					return
 				}
 			}
@@ -73,16 +85,72 @@ func EstablishWebSocketConnection() {
 			data := monitoring.GenerateReport()
 			err = conn.WriteMessage(websocket.TextMessage, data)
 			if err != nil {
-				log.Println("Failed to send WebSocket message:", err)
+				logger.Errorf(nil, "Failed to send WebSocket message: %v", err)
 				conn.Close()
 				conn = nil // Mark connection as dead
 				continue
 			}
+		case changes := <-watchdogEvents:
+			// 监视项状态变化立即推送，不等待下一次上报
+			if conn != nil {
+				payload := map[string]interface{}{
+					"type":    "watchdog_event",
+					"changes": changes,
+					"time":    time.Now(),
+				}
+				if err := conn.WriteJSON(payload); err != nil {
+					logger.Errorf(nil, "Failed to send watchdog event: %v", err)
+				}
+			}
+		case changes := <-diskHealthEvents:
+			if conn != nil {
+				payload := map[string]interface{}{
+					"type":    "disk_health_event",
+					"changes": changes,
+					"time":    time.Now(),
+				}
+				if err := conn.WriteJSON(payload); err != nil {
+					logger.Errorf(nil, "Failed to send disk health event: %v", err)
+				}
+			}
+		case changes := <-quotaEvents:
+			if conn != nil {
+				payload := map[string]interface{}{
+					"type":    "traffic_quota_event",
+					"changes": changes,
+					"time":    time.Now(),
+				}
+				if err := conn.WriteJSON(payload); err != nil {
+					logger.Errorf(nil, "Failed to send traffic quota event: %v", err)
+				}
+			}
+		case changes := <-interfaceEvents:
+			if conn != nil {
+				payload := map[string]interface{}{
+					"type":    "interface_event",
+					"changes": changes,
+					"time":    time.Now(),
+				}
+				if err := conn.WriteJSON(payload); err != nil {
+					logger.Errorf(nil, "Failed to send interface event: %v", err)
+				}
+			}
+		case changes := <-listenerEvents:
+			if conn != nil {
+				payload := map[string]interface{}{
+					"type":    "listener_event",
+					"changes": changes,
+					"time":    time.Now(),
+				}
+				if err := conn.WriteJSON(payload); err != nil {
+					logger.Errorf(nil, "Failed to send listener event: %v", err)
+				}
+			}
 		case <-heartbeatTicker.C:
 			if conn != nil {
 				err := conn.WriteMessage(websocket.PingMessage, nil)
 				if err != nil {
-					log.Println("Failed to send heartbeat:", err)
+					logger.Errorf(nil, "Failed to send heartbeat: %v", err)
 					conn.Close()
 					conn = nil // Mark connection as dead
 				}
@@ -112,7 +180,7 @@ func handleWebSocketMessages(conn *ws.SafeConn, done chan<- struct{}) {
 	for {
 		_, message_raw, err := conn.ReadMessage()
 		if err != nil {
-			log.Println("WebSocket read error:", err)
+			logger.Errorf(nil, "WebSocket read error: %v", err)
 			return
 		}
 		var message struct {
@@ -126,10 +194,21 @@ func handleWebSocketMessages(conn *ws.SafeConn, done chan<- struct{}) {
 			PingTaskID uint   `json:"ping_task_id,omitempty"`
 			PingType   string `json:"ping_type,omitempty"`
 			PingTarget string `json:"ping_target,omitempty"`
+			PingSource string `json:"ping_source,omitempty"`
+			PingFamily string `json:"ping_family,omitempty"`
+			// Traceroute
+			TracerouteType    string `json:"traceroute_type,omitempty"`
+			TracerouteTarget  string `json:"traceroute_target,omitempty"`
+			TracerouteMaxHops int    `json:"traceroute_max_hops,omitempty"`
+			// Speedtest
+			SpeedtestDownloadURL string `json:"speedtest_download_url,omitempty"`
+			SpeedtestUploadURL   string `json:"speedtest_upload_url,omitempty"`
+			SpeedtestDuration    int    `json:"speedtest_duration,omitempty"`
+			SpeedtestMaxBytes    int64  `json:"speedtest_max_bytes,omitempty"`
 		}
 		err = json.Unmarshal(message_raw, &message)
 		if err != nil {
-			log.Println("Bad ws message:", err)
+			logger.Errorf(nil, "Bad ws message: %v", err)
 			continue
 		}
 
@@ -141,8 +220,16 @@ func handleWebSocketMessages(conn *ws.SafeConn, done chan<- struct{}) {
 			go NewTask(message.ExecTaskID, message.ExecCommand)
 			continue
 		}
+		if message.Message == "speedtest" {
+			go NewSpeedtestTask(message.ExecTaskID, message.SpeedtestDownloadURL, message.SpeedtestUploadURL, message.SpeedtestDuration, message.SpeedtestMaxBytes)
+			continue
+		}
+		if message.Message == "traceroute" {
+			go NewTracerouteTask(message.ExecTaskID, message.TracerouteType, message.TracerouteTarget, message.TracerouteMaxHops)
+			continue
+		}
 		if message.Message == "ping" || message.PingTaskID != 0 || message.PingType != "" || message.PingTarget != "" {
-			go NewPingTask(conn, message.PingTaskID, message.PingType, message.PingTarget)
+			go NewPingTask(conn, message.PingTaskID, message.PingType, message.PingTarget, message.PingSource, message.PingFamily)
 			continue
 		}
 	}
@@ -162,12 +249,12 @@ func establishTerminalConnection(token, id, endpoint string) {
 
 	conn, _, err := dialer.Dial(endpoint, headers)
 	if err != nil {
-		log.Println("Failed to establish terminal connection:", err)
+		logger.Errorf(logger.Fields{"session_id": id}, "Failed to establish terminal connection: %v", err)
 		return
 	}
 
 	// 启动终端
-	terminal.StartTerminal(conn)
+	terminal.StartTerminal(conn, id)
 	if conn != nil {
 		conn.Close()
 	}
@@ -24,14 +24,14 @@ type terminalImpl struct {
 	term       Terminal
 }
 
-// StartTerminal 启动终端并处理 WebSocket 通信
-func StartTerminal(conn *websocket.Conn) {
+// StartTerminal 启动终端并处理 WebSocket 通信，sessionID 用于结构化日志
+func StartTerminal(conn *websocket.Conn, sessionID string) {
 	if flags.DisableWebSsh {
 		conn.WriteMessage(websocket.TextMessage, []byte("\n\nWeb SSH is disabled. Enable it by running without the --disable-web-ssh flag."))
 		conn.Close()
 		return
 	}
-	impl, err := newTerminalImpl()
+	impl, err := newTerminalImpl(sessionID)
 	if err != nil {
 		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("Error: %v\r\n", err)))
 		return
@@ -4,7 +4,6 @@ package terminal
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"strings"
@@ -12,14 +11,17 @@ import (
 	"time"
 
 	"github.com/creack/pty"
+	"github.com/komari-monitor/komari-agent/logger"
 )
 
 // newTerminalImpl 创建一个新的终端实例。
 // 它会尝试根据用户配置文件查找默认 shell，如果失败则回退到常见 shell。
 // 优先以交互模式启动 shell，如果不支持则回退到非交互模式。
-func newTerminalImpl() (*terminalImpl, error) {
+func newTerminalImpl(sessionID string) (*terminalImpl, error) {
+	fields := logger.Fields{"session_id": sessionID}
 	shell := ""
 	// 从 /etc/passwd 获取用户默认 shell
+	// 终端运行在 agent 自身的命名空间中，不受 --host-root 影响，这里读取的是容器内的 /etc/passwd
 	userHomeDir, err := os.UserHomeDir() // 获取当前用户的主目录
 	if err == nil {
 		passwdContent, err := os.ReadFile("/etc/passwd")
@@ -29,22 +31,22 @@ func newTerminalImpl() (*terminalImpl, error) {
 					parts := strings.Split(line, ":")
 					if len(parts) >= 7 && parts[6] != "" {
 						shell = parts[6]
-						log.Printf("Found shell from /etc/passwd: %s for user home: %s\n", shell, userHomeDir)
+						logger.Printf(fields, "Found shell from /etc/passwd: %s for user home: %s", shell, userHomeDir)
 						break
 					}
 				}
 			}
 		} else {
-			log.Printf("Error reading /etc/passwd: %v\n", err)
+			logger.Errorf(fields, "Error reading /etc/passwd: %v", err)
 		}
 	} else {
-		log.Printf("Error getting user home directory: %v\n", err)
+		logger.Errorf(fields, "Error getting user home directory: %v", err)
 	}
 
 	// 验证从 /etc/passwd 获取的 shell 是否可用
 	if shell != "" {
 		if _, err := exec.LookPath(shell); err != nil {
-			log.Printf("Shell '%s' from /etc/passwd not found in PATH, falling back.\n", shell)
+			logger.Printf(fields, "Shell '%s' from /etc/passwd not found in PATH, falling back.", shell)
 			shell = "" // 默认 shell 不可用，清空以进入回退逻辑
 		}
 	}
@@ -52,11 +54,11 @@ func newTerminalImpl() (*terminalImpl, error) {
 	// 回退到默认 shell 列表
 	defaultShells := []string{"zsh", "bash", "sh"}
 	if shell == "" {
-		log.Println("Shell not found or invalid, trying default shells.")
+		logger.Printf(fields, "Shell not found or invalid, trying default shells.")
 		for _, s := range defaultShells {
 			if _, err := exec.LookPath(s); err == nil {
 				shell = s
-				log.Printf("Using default shell: %s\n", shell)
+				logger.Printf(fields, "Using default shell: %s", shell)
 				break
 			}
 		}
@@ -76,7 +78,7 @@ func newTerminalImpl() (*terminalImpl, error) {
 
 	tty, err := pty.Start(cmd)
 	if err != nil {
-		log.Printf("Failed to start pty with -i (%s -i): %v. Retrying without -i.\n", shell, err)
+		logger.Printf(fields, "Failed to start pty with -i (%s -i): %v. Retrying without -i.", shell, err)
 		// 交互模式不被支持，回退到无 -i 的启动方式
 		cmd = exec.Command(shell)
 		cmd.Env = append(os.Environ(),
@@ -96,8 +98,9 @@ func newTerminalImpl() (*terminalImpl, error) {
 	return &terminalImpl{
 		shell: shell,
 		term: &unixTerminal{
-			tty: tty,
-			cmd: cmd,
+			tty:    tty,
+			cmd:    cmd,
+			fields: fields,
 		},
 	}, nil
 }
@@ -106,6 +109,8 @@ func newTerminalImpl() (*terminalImpl, error) {
 type unixTerminal struct {
 	tty *os.File  // 伪终端设备文件
 	cmd *exec.Cmd // 启动的 shell 进程命令
+
+	fields logger.Fields // 日志字段（session_id）
 }
 
 // Close 关闭终端，并尝试优雅地终止 shell 进程及其子进程。
@@ -118,12 +123,12 @@ func (t *unixTerminal) Close() error {
 	// 向进程组发送信号可以确保 shell 启动的子进程也能接收到信号。
 	pgid, err := syscall.Getpgid(t.cmd.Process.Pid)
 	if err != nil {
-		log.Printf("Failed to get process group ID for PID %d: %v. Using PID as PGID.\n", t.cmd.Process.Pid, err)
+		logger.Errorf(t.fields, "Failed to get process group ID for PID %d: %v. Using PID as PGID.", t.cmd.Process.Pid, err)
 		pgid = t.cmd.Process.Pid
 	}
 
 	// 发送 SIGTERM 信号，请求进程组优雅退出
-	log.Printf("Sending SIGTERM to process group %d...\n", pgid)
+	logger.Printf(t.fields, "Sending SIGTERM to process group %d...", pgid)
 	_ = syscall.Kill(-pgid, syscall.SIGTERM) // -pgid 表示发送给进程组
 
 	done := make(chan error, 1)
@@ -154,7 +159,7 @@ func (t *unixTerminal) Close() error {
 		if exitErr, ok := killErr.(*exec.ExitError); ok && exitErr.Exited() {
 			return nil
 		}
-		log.Printf("Failed to kill process group %d after SIGKILL: %v\n", pgid, killErr)
+		logger.Errorf(t.fields, "Failed to kill process group %d after SIGKILL: %v", pgid, killErr)
 		return fmt.Errorf("failed to kill process group %d: %v", pgid, killErr)
 	}
 }
@@ -12,7 +12,7 @@ import (
 	"github.com/UserExistsError/conpty"
 )
 
-func newTerminalImpl() (*terminalImpl, error) {
+func newTerminalImpl(sessionID string) (*terminalImpl, error) {
 	// 查找 shell
 	shell, err := exec.LookPath("powershell.exe")
 	if err != nil || shell == "" {